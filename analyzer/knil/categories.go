@@ -0,0 +1,96 @@
+package knil
+
+// This file contains the categories of the diagnostics
+// and their severities.
+
+import "fmt"
+
+// A severity is the severity of the diagnostics.
+type severity int
+
+const (
+	severityInfo severity = iota
+	severityWarning
+	severityError
+)
+
+var severityStrings = [...]string{"info", "warning", "error"}
+
+func (s severity) String() string { return severityStrings[s] }
+
+// parseSeverity returns the severity named s.
+func parseSeverity(s string) (severity, error) {
+	for i, n := range severityStrings {
+		if n == s {
+			return severity(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %s", s)
+}
+
+// A category is a kind of the diagnostics.
+type category struct {
+	name     string
+	severity severity
+	// description describes the diagnostics.
+	description string
+	// nilness is the nilness of the values of the diagnostics,
+	// "nil", "unknown" or empty if it depends on the diagnostic.
+	nilness string
+}
+
+// categories holds the categories of the diagnostics.
+var categories = []category{
+	{"nilderef", severityError, "dereference of a value which is definitely nil", "nil"},
+	{"maybe-nilderef", severityWarning, "dereference of a value which may be nil", "unknown"},
+	{"nilindex", severityError, "indexing of a nil slice, which is out of range", "nil"},
+	{"cond", severityWarning, "tautological or impossible nil comparison", ""},
+	{"contract", severityError, "violation of a nilness contract or a malformed directive", ""},
+	{"ignore", severityInfo, "suppression directive which suppresses nothing", ""},
+}
+
+// A Category describes a kind of the diagnostics
+// reported by the Analyzer for the drivers.
+type Category struct {
+	Name string
+	// Severity is the severity of the diagnostics,
+	// "info", "warning" or "error".
+	Severity    string
+	Description string
+	// Nilness is the nilness of the values of the diagnostics,
+	// "nil", "unknown" or empty if it depends on the diagnostic.
+	Nilness string
+}
+
+// Categories returns the categories of the
+// diagnostics reported by the Analyzer.
+func Categories() []Category {
+	cs := make([]Category, len(categories))
+	for i, c := range categories {
+		cs[i] = Category{c.name, c.severity.String(), c.description, c.nilness}
+	}
+	return cs
+}
+
+func categoryOf(name string) (category, bool) {
+	for _, c := range categories {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return category{}, false
+}
+
+func isCategory(name string) bool {
+	_, ok := categoryOf(name)
+	return ok
+}
+
+// severityOf returns the severity of the category.
+func severityOf(name string) severity {
+	c, ok := categoryOf(name)
+	if !ok {
+		return severityError
+	}
+	return c.severity
+}
@@ -0,0 +1,418 @@
+package knil
+
+// This file contains processes for the configuration file
+// .knil.yaml or .knil.json in the module root, such as
+//
+//	ignore:
+//	  files: ["*.pb.go", "mock_*.go"]
+//	  packages: ["example.com/app/gen/..."]
+//	categories: [nilderef, maybe-nilderef, nilindex]
+//	min-severity: warning
+//	exported-params: library
+//	guards: ["example.com/app/must.NotNil"]
+//	noreturn: ["example.com/app/log.Die"]
+//	contracts: ["knil.contracts.json"]
+//	tests: true
+//
+// The command line flags override the configuration.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+	"gopkg.in/yaml.v2"
+)
+
+// configFile is the path of the configuration file,
+// which is discovered from the module root if empty.
+var configFile string
+
+// enabledCategories is the comma-separated list
+// of the enabled categories.
+var enabledCategories string
+
+// minSeverity is the minimum severity
+// of the reported diagnostics.
+var minSeverity string
+
+// exportedParams is the policy for the parameters
+// of exported functions.
+var exportedParams string
+
+// The policies for the parameters of exported functions.
+const (
+	// policyApplication infers the nilness of the parameters
+	// only from the callers in the same package, assuming that
+	// the callers in other packages pass the same. The callers
+	// in other packages are not observed, and parameters of the
+	// functions without callers in the package are unknown.
+	policyApplication = "application"
+	// policyLibrary assumes that the parameters may be nil
+	// because any caller in other packages may pass nil.
+	policyLibrary = "library"
+	// policyTrusting assumes that the parameters are non-nil
+	// unless annotated with //knil:nilable.
+	policyTrusting = "trusting"
+)
+
+var exportedParamsPolicies = []string{policyApplication, policyLibrary, policyTrusting}
+
+// configNames holds the names of the configuration files.
+var configNames = []string{".knil.yaml", ".knil.yml", ".knil.json"}
+
+// defaultNoReturn holds the functions which never return
+// in the standard library.
+var defaultNoReturn = []string{
+	"os.Exit",
+	"runtime.Goexit",
+	"log.Fatal", "log.Fatalf", "log.Fatalln",
+	"log.Panic", "log.Panicf", "log.Panicln",
+	"(*log.Logger).Fatal", "(*log.Logger).Fatalf", "(*log.Logger).Fatalln",
+	"(*log.Logger).Panic", "(*log.Logger).Panicf", "(*log.Logger).Panicln",
+}
+
+// config is the configuration of the analysis.
+type config struct {
+	Ignore struct {
+		// Files holds the glob patterns of the ignored files,
+		// matched with the base names or the paths relative
+		// to the directory of the configuration file.
+		Files []string `json:"files" yaml:"files"`
+		// Packages holds the import paths of the packages
+		// whose diagnostics are ignored. The paths ending
+		// with "/..." match the packages under them.
+		Packages []string `json:"packages" yaml:"packages"`
+	} `json:"ignore" yaml:"ignore"`
+	// Categories holds the enabled categories,
+	// or all the categories are enabled if empty.
+	Categories []string `json:"categories" yaml:"categories"`
+	// MinSeverity is the minimum severity of the
+	// reported diagnostics.
+	MinSeverity string `json:"min-severity" yaml:"min-severity"`
+	// ExportedParams is the policy for the parameters
+	// of exported functions, one of exportedParamsPolicies.
+	ExportedParams string `json:"exported-params" yaml:"exported-params"`
+	// Guards holds the qualified names of the functions
+	// whose arguments are non-nil, or true for booleans,
+	// after they return.
+	Guards []string `json:"guards" yaml:"guards"`
+	// NoReturn holds the qualified names of the functions
+	// which never return in addition to defaultNoReturn.
+	NoReturn []string `json:"noreturn" yaml:"noreturn"`
+	// Contracts holds the external contract files,
+	// relative to the directory of the configuration file.
+	Contracts []string `json:"contracts" yaml:"contracts"`
+	// Concurrent is whether global variables can be
+	// set to nil concurrently at any time.
+	Concurrent bool `json:"concurrent" yaml:"concurrent"`
+	// Tests is whether the test files are analyzed.
+	Tests bool `json:"tests" yaml:"tests"`
+
+	// dir is the directory of the configuration file.
+	dir string
+
+	minSeverity severity
+	// guards maps the names of the guard functions to the
+	// indices of the guarded arguments, or nil for all.
+	guards   map[string][]int
+	noReturn map[string]struct{}
+}
+
+// loadedConfigs caches the configuration files by the paths.
+var loadedConfigs struct {
+	sync.Mutex
+	configs map[string]*config
+}
+
+// readConfig reads the configuration file at path.
+func readConfig(path string) (*config, error) {
+	loadedConfigs.Lock()
+	defer loadedConfigs.Unlock()
+	if c, ok := loadedConfigs.configs[path]; ok {
+		return c, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &config{}
+	if filepath.Ext(path) == ".json" {
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.DisallowUnknownFields()
+		err = d.Decode(c)
+	} else {
+		err = yaml.UnmarshalStrict(b, c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	c.dir = filepath.Dir(path)
+	for i, f := range c.Contracts {
+		if !filepath.IsAbs(f) {
+			c.Contracts[i] = filepath.Join(c.dir, f)
+		}
+	}
+	if loadedConfigs.configs == nil {
+		loadedConfigs.configs = make(map[string]*config)
+	}
+	loadedConfigs.configs[path] = c
+	return c, nil
+}
+
+// findConfig returns the path of the configuration file in
+// the module root containing dir, or empty if not found.
+func findConfig(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			for _, n := range configNames {
+				path := filepath.Join(dir, n)
+				if _, err := os.Stat(path); err == nil {
+					return path
+				}
+			}
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadConfig returns the configuration for the package
+// overridden by the command line flags.
+func loadConfig(pass *analysis.Pass) (*config, error) {
+	path := configFile
+	if path == "" && len(pass.Files) > 0 {
+		path = findConfig(filepath.Dir(pass.Fset.Position(pass.Files[0].Pos()).Filename))
+	}
+	c := config{}
+	if path != "" {
+		fc, err := readConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		c = *fc
+	}
+
+	if enabledCategories != "" {
+		c.Categories = splitList(enabledCategories)
+	}
+	if minSeverity != "" {
+		c.MinSeverity = minSeverity
+	}
+	if exportedParams != "" {
+		c.ExportedParams = exportedParams
+	}
+	if contractFiles != "" {
+		c.Contracts = splitList(contractFiles)
+	}
+	if concurrent.set {
+		c.Concurrent = concurrent.value
+	}
+	if analyzeTests.set {
+		c.Tests = analyzeTests.value
+	}
+
+	for _, cat := range c.Categories {
+		if !isCategory(cat) {
+			return nil, fmt.Errorf("unknown category %s", cat)
+		}
+	}
+	if c.MinSeverity != "" {
+		s, err := parseSeverity(c.MinSeverity)
+		if err != nil {
+			return nil, err
+		}
+		c.minSeverity = s
+	}
+	switch c.ExportedParams {
+	case "":
+		c.ExportedParams = policyApplication
+	case policyApplication, policyLibrary, policyTrusting:
+	default:
+		return nil, fmt.Errorf("unknown policy for exported parameters %s, want one of %s",
+			c.ExportedParams, strings.Join(exportedParamsPolicies, ", "))
+	}
+	c.guards = make(map[string][]int)
+	for n, is := range testGuards {
+		c.guards[n] = is
+	}
+	for _, n := range c.Guards {
+		c.guards[n] = nil
+	}
+	c.noReturn = nameSet(append(append(append([]string(nil), defaultNoReturn...), testNoReturn...), c.NoReturn...))
+	return &c, nil
+}
+
+func splitList(s string) []string {
+	var l []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			l = append(l, e)
+		}
+	}
+	return l
+}
+
+func nameSet(names []string) map[string]struct{} {
+	s := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		s[n] = struct{}{}
+	}
+	return s
+}
+
+// isEnabled reports whether the diagnostics of
+// the category are enabled.
+func (c *config) isEnabled(category string) bool {
+	if severityOf(category) < c.minSeverity {
+		return false
+	}
+	if len(c.Categories) == 0 {
+		return true
+	}
+	for _, cat := range c.Categories {
+		if cat == category {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnoredFile reports whether the file is ignored
+// by the default pattern or the configuration.
+func (c *config) isIgnoredFile(name string) bool {
+	if isIgnoredFile(name) && !(c.Tests && isTestFile(name)) {
+		return true
+	}
+	rel, err := filepath.Rel(c.dir, name)
+	if err != nil {
+		rel = name
+	}
+	for _, g := range c.Ignore.Files {
+		if m, _ := filepath.Match(g, filepath.Base(name)); m {
+			return true
+		}
+		if m, _ := filepath.Match(g, filepath.ToSlash(rel)); m {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *config) isIgnoredFunction(fn *ssa.Function) bool {
+	return c.isIgnoredFile(getFileNameOfFunction(fn))
+}
+
+// isIgnoredPackage reports whether the diagnostics
+// of the package are ignored.
+func (c *config) isIgnoredPackage(path string) bool {
+	for _, p := range c.Ignore.Packages {
+		if p == path {
+			return true
+		}
+		if strings.HasSuffix(p, "/...") {
+			prefix := strings.TrimSuffix(p, "/...")
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterReports returns a copy of pass whose Report drops
+// the diagnostics of disabled categories or ignored files,
+// or all the diagnostics while answering a -why query.
+func (c *config) filterReports(pass *analysis.Pass) *analysis.Pass {
+	p := *pass
+	p.Report = func(d analysis.Diagnostic) {
+		if whyPosition != "" {
+			return
+		}
+		if !c.isEnabled(d.Category) || c.isIgnoredFile(pass.Fset.Position(d.Pos).Filename) {
+			return
+		}
+		pass.Report(d)
+	}
+	return &p
+}
+
+// calleeName returns the qualified name of the static
+// callee of the call or the invoked interface method,
+// or empty if there is none.
+func calleeName(c *ssa.CallCommon) string {
+	if c.IsInvoke() {
+		return c.Method.FullName()
+	}
+	s := c.StaticCallee()
+	if s == nil || s.Object() == nil {
+		return ""
+	}
+	return s.Object().(*types.Func).FullName()
+}
+
+// isNoReturn reports whether instr is a call to a
+// function which never returns.
+func (c *config) isNoReturn(instr ssa.Instruction) bool {
+	call, ok := instr.(ssa.CallInstruction)
+	if !ok {
+		return false
+	}
+	_, ok = c.noReturn[calleeName(call.Common())]
+	return ok
+}
+
+// isDead reports whether b never reaches its successors
+// because it calls a function which never returns.
+func (c *config) isDead(b *ssa.BasicBlock) bool {
+	for _, instr := range b.Instrs {
+		if c.isNoReturn(instr) {
+			return true
+		}
+	}
+	return false
+}
+
+// livePreds returns the predecessors of b which may
+// reach b, excluding the dead ones.
+func (c *config) livePreds(b *ssa.BasicBlock) []*ssa.BasicBlock {
+	var ps []*ssa.BasicBlock
+	for _, p := range b.Preds {
+		if !c.isDead(p) {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// A boolFlag is a boolean flag which records whether it is set,
+// so that only the set flags override the configuration.
+type boolFlag struct {
+	value, set bool
+}
+
+func (b *boolFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b.value, b.set = v, true
+	return nil
+}
+
+func (b *boolFlag) String() string { return strconv.FormatBool(b.value) }
+
+func (b *boolFlag) IsBoolFlag() bool { return true }
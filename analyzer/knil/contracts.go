@@ -0,0 +1,410 @@
+package knil
+
+// This file contains processes for nilness contracts declared
+// with comment directives on function declarations, such as
+//
+//	//knil:nonnil p
+//	//knil:nilable return
+//	//knil:returns-nonnil-if err==nil
+//
+// and //knil:nonnil or //knil:nilable on struct fields.
+// The contracts are exported as facts, so that importing
+// packages can rely on the guarantees of the APIs, which
+// inference can't see across package boundaries.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+const directivePrefix = "//knil:"
+
+// annotation is a nilness contract of a value.
+type annotation int
+
+const (
+	notAnnotated annotation = iota
+	annotatedNonnil
+	annotatedNilable
+)
+
+var annotationStrings = [...]string{"-", "nonnil", "nilable"}
+
+func (a annotation) String() string { return annotationStrings[a] }
+
+// nilness returns the nilness which the annotation guarantees.
+// Nilable values may be nil whatever the callers pass, and the
+// unknown nilness of them precedes the facts of the callers.
+func (a annotation) nilness() nilness {
+	switch a {
+	case annotatedNonnil:
+		return isnonnil
+	case annotatedNilable:
+		return unknown
+	}
+	return unknown
+}
+
+type annotations []annotation
+
+// contractInfo holds the nilness contract of a function
+// or a struct field.
+type contractInfo struct {
+	// params holds the annotations of the parameters of a function,
+	// including the receiver as the first one as ssa.Function.Params.
+	params annotations
+	// results holds the annotations of the results of a function.
+	results annotations
+	// nonnilIfNoError is whether the results other than the last
+	// error are non-nil if the error is nil.
+	nonnilIfNoError bool
+
+	// field holds the annotation of a struct field.
+	field annotation
+}
+
+func (ci contractInfo) String() string {
+	if ci.field != notAnnotated {
+		return fmt.Sprintf("contract: %v", ci.field)
+	}
+	s := fmt.Sprintf("contract: params %v, results %v", ci.params, ci.results)
+	if ci.nonnilIfNoError {
+		s += ", nonnil if err == nil"
+	}
+	return s
+}
+
+func (*contractInfo) AFact() {}
+
+// exportContracts parses the directives in the files of the
+// package and exports the contracts as facts.
+// Malformed directives are reported.
+func exportContracts(pass *analysis.Pass) {
+	for _, f := range pass.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				obj, ok := pass.TypesInfo.Defs[n.Name].(*types.Func)
+				if !ok {
+					return true
+				}
+				if ci := parseFuncContract(pass, n, obj); ci != nil {
+					pass.ExportObjectFact(obj, ci)
+				}
+			case *ast.StructType:
+				for _, field := range n.Fields.List {
+					exportFieldContract(pass, field)
+				}
+			}
+			return true
+		})
+	}
+}
+
+// directives returns the knil directives in the comment groups.
+func directives(cgs ...*ast.CommentGroup) []*ast.Comment {
+	var ds []*ast.Comment
+	for _, cg := range cgs {
+		if cg == nil {
+			continue
+		}
+		for _, c := range cg.List {
+			if strings.HasPrefix(c.Text, directivePrefix) {
+				ds = append(ds, c)
+			}
+		}
+	}
+	return ds
+}
+
+// directiveFields returns the fields of the directive d,
+// dropping the trailing comment after "//" if any.
+func directiveFields(d *ast.Comment) []string {
+	text := strings.TrimPrefix(d.Text, directivePrefix)
+	if i := strings.Index(text, "//"); i >= 0 {
+		text = text[:i]
+	}
+	return strings.Fields(text)
+}
+
+func reportContractf(pass *analysis.Pass, pos token.Pos, format string, args ...interface{}) {
+	pass.Report(analysis.Diagnostic{
+		Pos:      pos,
+		Category: "contract",
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// parseFuncContract returns the contract declared in the
+// doc comment of decl, or nil if there is no contract.
+func parseFuncContract(pass *analysis.Pass, decl *ast.FuncDecl, obj *types.Func) *contractInfo {
+	ds := directives(decl.Doc)
+	if len(ds) == 0 {
+		return nil
+	}
+	dfs := make([][]string, len(ds))
+	for i, d := range ds {
+		dfs[i] = directiveFields(d)
+	}
+	return funcContract(obj.Type().(*types.Signature), dfs, func(i int, format string, args ...interface{}) {
+		reportContractf(pass, ds[i].Pos(), format, args...)
+	})
+}
+
+// funcContract returns the contract of a function of sig
+// declared by the fields of the directives dfs, or nil if
+// there is no contract. Malformed directives are reported
+// with the index of them.
+func funcContract(sig *types.Signature, dfs [][]string, report func(i int, format string, args ...interface{})) *contractInfo {
+	params := paramVars(sig)
+	ci := &contractInfo{
+		params:  make(annotations, len(params)),
+		results: make(annotations, sig.Results().Len()),
+	}
+	found := false
+	for di, fs := range dfs {
+		if len(fs) == 0 {
+			continue
+		}
+		text := directivePrefix + strings.Join(fs, " ")
+		switch fs[0] {
+		case "nonnil", "nilable":
+			a := annotatedNonnil
+			if fs[0] == "nilable" {
+				a = annotatedNilable
+			}
+			if len(fs) == 1 {
+				report(di, "missing names in %s", text)
+			}
+		names:
+			for _, name := range fs[1:] {
+				if name == "return" {
+					for i := range ci.results {
+						ci.results[i] = a
+					}
+					found = true
+					continue
+				}
+				for i, p := range params {
+					if p.Name() == name {
+						if !isNillable(p.Type()) {
+							report(di, "%s is never nil", name)
+							continue names
+						}
+						ci.params[i] = a
+						found = true
+						continue names
+					}
+				}
+				for i := 0; i < sig.Results().Len(); i++ {
+					if sig.Results().At(i).Name() == name {
+						ci.results[i] = a
+						found = true
+						continue names
+					}
+				}
+				report(di, "unknown name %s in %s", name, text)
+			}
+		case "returns-nonnil-if":
+			n := sig.Results().Len()
+			if n < 2 || !types.Identical(sig.Results().At(n-1).Type(), errorType) {
+				report(di, "%s requires the last result of type error", text)
+				continue
+			}
+			name := sig.Results().At(n - 1).Name()
+			if name == "" || name == "_" {
+				name = "err"
+			}
+			if cond := strings.Join(fs[1:], ""); cond != name+"==nil" {
+				report(di, "unsupported condition %s in %s", cond, text)
+				continue
+			}
+			ci.nonnilIfNoError = true
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return ci
+}
+
+// paramVars returns the parameters of sig including
+// the receiver as the first one as ssa.Function.Params.
+func paramVars(sig *types.Signature) []*types.Var {
+	var params []*types.Var
+	if sig.Recv() != nil {
+		params = append(params, sig.Recv())
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		params = append(params, sig.Params().At(i))
+	}
+	return params
+}
+
+// exportFieldContract exports the contract declared in the
+// comments of the struct field.
+func exportFieldContract(pass *analysis.Pass, field *ast.Field) {
+	ds := directives(field.Doc, field.Comment)
+	if len(ds) == 0 {
+		return
+	}
+	dfs := make([][]string, len(ds))
+	for i, d := range ds {
+		dfs[i] = directiveFields(d)
+	}
+	a := fieldAnnotation(dfs)
+	if a == notAnnotated {
+		return
+	}
+
+	idents := field.Names
+	if len(idents) == 0 {
+		// The identifier of an embedded field is the type name.
+		t := field.Type
+		if st, ok := t.(*ast.StarExpr); ok {
+			t = st.X
+		}
+		if sel, ok := t.(*ast.SelectorExpr); ok {
+			t = sel.Sel
+		}
+		if id, ok := t.(*ast.Ident); ok {
+			idents = []*ast.Ident{id}
+		}
+	}
+	for _, id := range idents {
+		obj, ok := pass.TypesInfo.Defs[id].(*types.Var)
+		if !ok {
+			continue
+		}
+		if !isNillable(obj.Type()) {
+			reportContractf(pass, id.Pos(), "%s is never nil", id.Name)
+			continue
+		}
+		pass.ExportObjectFact(obj, &contractInfo{field: a})
+	}
+}
+
+// fieldAnnotation returns the annotation of a struct
+// field declared by the fields of the directives dfs.
+func fieldAnnotation(dfs [][]string) annotation {
+	a := notAnnotated
+	for _, fs := range dfs {
+		if len(fs) == 0 {
+			continue
+		}
+		switch fs[0] {
+		case "nonnil":
+			a = annotatedNonnil
+		case "nilable":
+			a = annotatedNilable
+		}
+	}
+	return a
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// contractOf returns the contract of the object, declared
+// in the source or in the external contract files.
+func contractOf(pass *analysis.Pass, obj types.Object) (contractInfo, bool) {
+	ci := contractInfo{}
+	if obj == nil {
+		return ci, false
+	}
+	if pass.ImportObjectFact(obj, &ci) {
+		return ci, true
+	}
+	return externalContract(obj)
+}
+
+// calleeContract returns the contract of the static callee of
+// c with the parameter annotations aligned to the arguments.
+func calleeContract(pass *analysis.Pass, c *ssa.CallCommon) (contractInfo, bool) {
+	s := c.StaticCallee()
+	if s == nil {
+		return contractInfo{}, false
+	}
+	ci, ok := contractOf(pass, s.Object())
+	if !ok || ci.field != notAnnotated {
+		return contractInfo{}, false
+	}
+	// Bound method closures take the receiver as a free variable.
+	if len(ci.params) == len(c.Args)+1 {
+		ci.params = ci.params[1:]
+	}
+	if len(ci.params) != len(c.Args) {
+		return contractInfo{}, false
+	}
+	return ci, true
+}
+
+// applyResultContract overrides the nilness of the results
+// of the static callee of c with the contract.
+func applyResultContract(pass *analysis.Pass, c *ssa.CallCommon, ns nilnesses) nilnesses {
+	ci, ok := calleeContract(pass, c)
+	if !ok {
+		return ns
+	}
+	if len(ns) != len(ci.results) {
+		ns = make(nilnesses, len(ci.results))
+	} else {
+		ns = append(nilnesses(nil), ns...)
+	}
+	for i, a := range ci.results {
+		if a != notAnnotated {
+			ns[i] = a.nilness()
+		}
+	}
+	return ns
+}
+
+// impliedFacts returns the facts implied by f with the
+// contracts, such as non-nil results if the error is nil.
+func impliedFacts(pass *analysis.Pass, f nilnessOfValue) []nilnessOfValue {
+	e, ok := f.value.(*ssa.Extract)
+	if !ok || f.nilness != isnil {
+		return nil
+	}
+	c, ok := e.Tuple.(*ssa.Call)
+	if !ok {
+		return nil
+	}
+	ci, ok := calleeContract(pass, c.Common())
+	if !ok || !ci.nonnilIfNoError || e.Index != len(ci.results)-1 {
+		return nil
+	}
+	var fs []nilnessOfValue
+	for _, r := range *c.Referrers() {
+		if e2, ok := r.(*ssa.Extract); ok && e2.Index != e.Index {
+			fs = append(fs, nilnessOfValue{e2, isnonnil})
+		}
+	}
+	return fs
+}
+
+// withImpliedFacts returns the stack with f and the facts
+// implied by f. The implied facts precede the stack because
+// the results of calls are already on it as unknown.
+func withImpliedFacts(pass *analysis.Pass, stack []nilnessOfValue, f nilnessOfValue) []nilnessOfValue {
+	ifs := impliedFacts(pass, f)
+	if len(ifs) == 0 {
+		return append(stack, f)
+	}
+	return append(append(ifs, stack...), f)
+}
+
+// describe describes the nilness of a value
+// which violates a contract.
+func describe(n nilness) string {
+	if n == isnil {
+		return "nil"
+	}
+	return "possibly nil"
+}
@@ -0,0 +1,131 @@
+package knil
+
+// This file contains processes for explaining why a value
+// may be nil, by tracing it back to its origins such as the
+// call sites passing nil, the return statements returning
+// nil, or the comparisons establishing it. The origins are
+// attached to the diagnostics as related information.
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+// An origin is where a fact on the stack is established.
+type origin struct {
+	pos     token.Pos
+	message string
+}
+
+// origins holds the origins of the facts on the stack.
+type origins map[nilnessOfValue]origin
+
+// record records that the fact f is established at pos
+// with the message formatted with the nilness of f,
+// unless the origin of f is already recorded.
+func (origs origins) record(f nilnessOfValue, pos token.Pos, format string) {
+	if _, ok := origs[f]; ok || !pos.IsValid() {
+		return
+	}
+	origs[f] = origin{pos, fmt.Sprintf(format, f.nilness)}
+}
+
+// factOf returns the fact of v which nilnessOf uses.
+func factOf(stack []nilnessOfValue, v ssa.Value) (nilnessOfValue, bool) {
+	for _, f := range stack {
+		if f.value == v {
+			return f, true
+		}
+	}
+	return nilnessOfValue{}, false
+}
+
+// explain returns the related information tracing the
+// nilness of v in fn back to its origins.
+func explain(pass *analysis.Pass, fn *ssa.Function, stack []nilnessOfValue, origs origins, v ssa.Value) []analysis.RelatedInformation {
+	// The comparison or the guard establishing the nilness.
+	if f, ok := factOf(stack, v); ok {
+		if o, ok := origs[f]; ok {
+			return []analysis.RelatedInformation{{Pos: o.pos, Message: o.message}}
+		}
+	}
+	return summaryTrace(pass, fn, v)
+}
+
+// summaryTrace returns the related information tracing the
+// nilness of v in fn through the summaries of the functions,
+// such as the call sites passing nil to a parameter or the
+// return statements returning nil from a callee.
+func summaryTrace(pass *analysis.Pass, fn *ssa.Function, v ssa.Value) []analysis.RelatedInformation {
+	var related []analysis.RelatedInformation
+	add := func(pos token.Pos, format string, args ...interface{}) {
+		if !pos.IsValid() {
+			return
+		}
+		related = append(related, analysis.RelatedInformation{
+			Pos:     pos,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	switch v := v.(type) {
+	case *ssa.Parameter:
+		// The call sites passing nil.
+		fi := functionInfo{}
+		if fn.Object() == nil || !pass.ImportObjectFact(fn.Object(), &fi) {
+			break
+		}
+		i := paramIndex(fn, v)
+		if i < 0 {
+			break
+		}
+		// Method closures take the receiver as the first argument.
+		if fi.na.length() == len(fn.Params)+1 {
+			i++
+		}
+		for pos, ns := range fi.na {
+			if i < len(ns) && ns[i] != isnonnil {
+				add(pos, "%s passed as %s here", describe(ns[i]), v.Name())
+			}
+		}
+	case *ssa.Call, *ssa.Extract:
+		// The return statements returning nil.
+		index := 0
+		c, ok := v.(*ssa.Call)
+		if e, isExtract := v.(*ssa.Extract); isExtract {
+			c, ok = e.Tuple.(*ssa.Call)
+			index = e.Index
+		}
+		if !ok {
+			break
+		}
+		s := c.Call.StaticCallee()
+		if s == nil || s.Object() == nil {
+			break
+		}
+		fi := functionInfo{}
+		if !pass.ImportObjectFact(s.Object(), &fi) {
+			break
+		}
+		for pos, ns := range fi.nr {
+			if index < len(ns) && ns[index] != isnonnil {
+				add(pos, "%s returned here by %s", describe(ns[index]), s.Name())
+			}
+		}
+	}
+	sort.Slice(related, func(i, j int) bool { return related[i].Pos < related[j].Pos })
+	return related
+}
+
+func paramIndex(fn *ssa.Function, p *ssa.Parameter) int {
+	for i, q := range fn.Params {
+		if q == p {
+			return i
+		}
+	}
+	return -1
+}
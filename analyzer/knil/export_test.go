@@ -0,0 +1,23 @@
+package knil
+
+import "testing"
+
+// SetFlag sets the flag of the Analyzer for the test, and restores
+// the value and whether it is set after the test, like preserve of
+// the checker tests.
+func SetFlag(t *testing.T, name, value string) {
+	f := Analyzer.Flags.Lookup(name)
+	if f == nil {
+		t.Fatalf("no flag %s", name)
+	}
+	if b, ok := f.Value.(*boolFlag); ok {
+		old := *b
+		t.Cleanup(func() { *b = old })
+	} else {
+		old := f.Value.String()
+		t.Cleanup(func() { f.Value.Set(old) })
+	}
+	if err := f.Value.Set(value); err != nil {
+		t.Fatal(err)
+	}
+}
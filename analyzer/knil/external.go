@@ -0,0 +1,245 @@
+package knil
+
+// This file contains processes for nilness contracts listed in
+// external contract files, for the packages which can't be
+// annotated in the source such as vendored SDKs or generated
+// clients. A contract file is a JSON object mapping the fully
+// qualified names of functions, methods and struct fields to
+// the directives without the //knil: prefix, such as
+//
+//	{
+//		"example.com/sdk.Dial": ["nonnil addr", "returns-nonnil-if err==nil"],
+//		"(*example.com/sdk.Client).Get": ["nilable return"],
+//		"example.com/sdk.Conn.Addr": ["nonnil"]
+//	}
+//
+// The contracts are treated as if they were imported as facts
+// of the named objects. The entries of the analyzed packages
+// naming no objects or not matching the signatures are errors.
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// contractFiles is the comma-separated list of the
+// external contract files.
+var contractFiles string
+
+// externalContracts holds the directives loaded from the
+// external contract files.
+var externalContracts struct {
+	sync.Mutex
+	// files is the list of the loaded files.
+	files string
+	// directives maps the qualified names to the
+	// fields of the directives.
+	directives map[string][][]string
+	// fields maps the names of struct fields to the
+	// qualified names of them.
+	fields map[string][]string
+	// origins maps the qualified names to
+	// the files listing them.
+	origins map[string]string
+}
+
+// loadContractFiles loads the external contract files
+// unless they are already loaded.
+func loadContractFiles(files []string) error {
+	externalContracts.Lock()
+	defer externalContracts.Unlock()
+	key := strings.Join(files, ",")
+	if externalContracts.directives != nil && externalContracts.files == key {
+		return nil
+	}
+	ds := make(map[string][][]string)
+	fields := make(map[string][]string)
+	origins := make(map[string]string)
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var cs map[string][]string
+		if err := json.Unmarshal(b, &cs); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		for name, texts := range cs {
+			for _, text := range texts {
+				fs := strings.Fields(strings.TrimPrefix(text, directivePrefix))
+				if len(fs) == 0 {
+					continue
+				}
+				switch fs[0] {
+				case "nonnil", "nilable", "returns-nonnil-if":
+				default:
+					return fmt.Errorf("%s: unknown directive %s for %s", file, fs[0], name)
+				}
+				ds[name] = append(ds[name], fs)
+			}
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				fields[name[i+1:]] = append(fields[name[i+1:]], name)
+			}
+			origins[name] = file
+		}
+	}
+	externalContracts.files = key
+	externalContracts.directives = ds
+	externalContracts.fields = fields
+	externalContracts.origins = origins
+	return nil
+}
+
+// checkContractFiles returns an error for the first entry of
+// the external contract files in the package of the pass which
+// names no function, method or struct field, or whose directives
+// don't match it, so that a typo doesn't disable the contract.
+func checkContractFiles(pass *analysis.Pass) error {
+	externalContracts.Lock()
+	ds, origins := externalContracts.directives, externalContracts.origins
+	externalContracts.Unlock()
+	var names []string
+	for name := range origins {
+		if inPackage(name, pass.Pkg.Path()) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	objs := packageObjects(pass.Pkg)
+	for _, name := range names {
+		var msg string
+		report := func(_ int, format string, args ...interface{}) {
+			if msg == "" {
+				msg = fmt.Sprintf(format, args...)
+			}
+		}
+		switch obj := objs[name].(type) {
+		case *types.Func:
+			funcContract(obj.Type().(*types.Signature), ds[name], report)
+		case *types.Var:
+			if !isNillable(obj.Type()) {
+				report(0, "%s is never nil", obj.Name())
+			}
+		default:
+			report(0, "no function, method or struct field")
+		}
+		if msg != "" {
+			return fmt.Errorf("%s: %s: %s", origins[name], name, msg)
+		}
+	}
+	return nil
+}
+
+// inPackage reports whether the qualified name
+// is of an object in the package of path.
+func inPackage(name, path string) bool {
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "("), "*")
+	rest := strings.TrimPrefix(name, path+".")
+	return rest != name && !strings.Contains(rest, "/")
+}
+
+// packageObjects returns the functions, methods and struct
+// fields of the named types at the package level of pkg by
+// the qualified names of them.
+func packageObjects(pkg *types.Package) map[string]types.Object {
+	objs := make(map[string]types.Object)
+	scope := pkg.Scope()
+	for _, n := range scope.Names() {
+		switch obj := scope.Lookup(n).(type) {
+		case *types.Func:
+			objs[obj.FullName()] = obj
+		case *types.TypeName:
+			named, ok := obj.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			for i := 0; i < named.NumMethods(); i++ {
+				m := named.Method(i)
+				objs[m.FullName()] = m
+			}
+			st, ok := named.Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			for i := 0; i < st.NumFields(); i++ {
+				f := st.Field(i)
+				objs[pkg.Path()+"."+n+"."+f.Name()] = f
+			}
+		}
+	}
+	return objs
+}
+
+// externalContract returns the contract of obj listed
+// in the external contract files. Directives which don't
+// match the signature are ignored here, and reported by
+// checkContractFiles in the pass of the package of obj.
+func externalContract(obj types.Object) (contractInfo, bool) {
+	externalContracts.Lock()
+	ds, fields := externalContracts.directives, externalContracts.fields
+	externalContracts.Unlock()
+	if len(ds) == 0 || obj.Pkg() == nil {
+		return contractInfo{}, false
+	}
+
+	switch obj := obj.(type) {
+	case *types.Func:
+		dfs, ok := ds[obj.FullName()]
+		if !ok {
+			return contractInfo{}, false
+		}
+		ci := funcContract(obj.Type().(*types.Signature), dfs, func(int, string, ...interface{}) {})
+		if ci == nil {
+			return contractInfo{}, false
+		}
+		return *ci, true
+	case *types.Var:
+		if !obj.IsField() || !isNillable(obj.Type()) {
+			return contractInfo{}, false
+		}
+		for _, name := range fields[obj.Name()] {
+			if !isFieldNamed(obj, name) {
+				continue
+			}
+			if a := fieldAnnotation(ds[name]); a != notAnnotated {
+				return contractInfo{field: a}, true
+			}
+		}
+	}
+	return contractInfo{}, false
+}
+
+// isFieldNamed reports whether the qualified name of the
+// struct field f is name, such as "example.com/sdk.Conn.Addr".
+func isFieldNamed(f *types.Var, name string) bool {
+	typeName := strings.TrimSuffix(name, "."+f.Name())
+	i := strings.LastIndex(typeName, ".")
+	if i < 0 || typeName[:i] != f.Pkg().Path() {
+		return false
+	}
+	tn, ok := f.Pkg().Scope().Lookup(typeName[i+1:]).(*types.TypeName)
+	if !ok {
+		return false
+	}
+	st, ok := tn.Type().Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i) == f {
+			return true
+		}
+	}
+	return false
+}
@@ -30,3 +30,61 @@ type alreadyReportedGlobal struct{}
 func (alreadyReportedGlobal) String() string { return "already reported global" }
 
 func (*alreadyReportedGlobal) AFact() {}
+
+// globalState is the state of a global variable
+// after the package initialization.
+type globalState int
+
+const (
+	// globalMayBeNil means that the global variable
+	// may be set to nil at any time.
+	globalMayBeNil globalState = iota
+	// globalInitOnly means that the global variable is
+	// written only in the package initialization, but
+	// may be nil.
+	globalInitOnly
+	// globalNeverNil means that the global variable is
+	// never nil after the package initialization.
+	globalNeverNil
+)
+
+var globalStateStrings = [...]string{"may be nil", "written only in init", "never nil"}
+
+func (s globalState) String() string { return globalStateStrings[s] }
+
+type globalInfo struct {
+	state globalState
+}
+
+func (gi globalInfo) String() string { return "global: " + gi.state.String() }
+
+func (*globalInfo) AFact() {}
+
+// guardKind is the kind of the guard of a parameter.
+type guardKind int
+
+const (
+	notGuarded guardKind = iota
+	// guardedNonnil means that the argument is
+	// non-nil after the call returns.
+	guardedNonnil
+	// guardedTrue means that the boolean argument
+	// is true after the call returns.
+	guardedTrue
+)
+
+var guardKindStrings = [...]string{"-", "nonnil", "true"}
+
+func (k guardKind) String() string { return guardKindStrings[k] }
+
+type guardKinds []guardKind
+
+// guardInfo holds the guards of the parameters of a function
+// which panics or exits unless they are non-nil or true.
+type guardInfo struct {
+	params guardKinds
+}
+
+func (gi guardInfo) String() string { return fmt.Sprintf("guard: %v", gi.params) }
+
+func (*guardInfo) AFact() {}
@@ -0,0 +1,176 @@
+package knil
+
+// This file contains helpers for struct field selections,
+// such as promoted fields through embedded pointers and
+// map fields never initialized in the package.
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
+)
+
+// fieldOf returns the field selected by fa.
+func fieldOf(fa *ssa.FieldAddr) *types.Var {
+	st := fa.X.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct)
+	return st.Field(fa.Field)
+}
+
+// selection returns the struct type and the index of
+// the field if v is the value of a field, or nil otherwise.
+func selection(v ssa.Value) (types.Type, int) {
+	switch v := v.(type) {
+	case *ssa.UnOp:
+		fa, ok := v.X.(*ssa.FieldAddr)
+		if !ok || v.Op != token.MUL {
+			return nil, 0
+		}
+		return fa.X.Type().Underlying().(*types.Pointer).Elem(), fa.Field
+	case *ssa.Field:
+		return v.X.Type(), v.Field
+	}
+	return nil, 0
+}
+
+// loadedField returns the field if v is the value
+// of the field, or nil otherwise.
+func loadedField(v ssa.Value) *types.Var {
+	t, i := selection(v)
+	if t == nil {
+		return nil
+	}
+	return t.Underlying().(*types.Struct).Field(i)
+}
+
+// fieldName returns the name of the field qualified by
+// the struct type if v is the value of the field.
+func fieldName(pkg *types.Package, v ssa.Value) string {
+	t, i := selection(v)
+	if t == nil {
+		return ""
+	}
+	f := t.Underlying().(*types.Struct).Field(i)
+	return types.TypeString(t, types.RelativeTo(pkg)) + "." + f.Name()
+}
+
+// embeddedField returns the embedded pointer field
+// if v is the value of the field, or nil otherwise.
+//
+// Accessing a promoted field through an embedded
+// pointer compiles to a load of the embedded field
+// followed by the selection, so v is the operand of
+// the selection in such cases.
+func embeddedField(v ssa.Value) *types.Var {
+	f := loadedField(v)
+	if f == nil || !f.Anonymous() {
+		return nil
+	}
+	if _, ok := f.Type().Underlying().(*types.Pointer); !ok {
+		return nil
+	}
+	return f
+}
+
+// packageFuncs returns all the functions in the package,
+// including the package initializer and its anonymous
+// functions, which hold package level variable initializers.
+func packageFuncs(ssainput *buildssa.SSA) []*ssa.Function {
+	fns := ssainput.SrcFuncs
+	if init := ssainput.Pkg.Func("init"); init != nil {
+		fns = append(append([]*ssa.Function{init}, init.AnonFuncs...), fns...)
+	}
+	return fns
+}
+
+// uninitializedMapFields returns the unexported map fields
+// of the structs in pkg which are updated in fns but never
+// initialized there, so the updates always panic.
+// Only the unexported fields are returned because other
+// packages may initialize the exported ones.
+func uninitializedMapFields(pkg *types.Package, fns []*ssa.Function) map[*types.Var]struct{} {
+	updated := make(map[*types.Var]struct{})
+	initialized := make(map[*types.Var]struct{})
+	for _, fn := range fns {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.MapUpdate:
+					if f := loadedField(instr.Map); f != nil {
+						updated[f] = struct{}{}
+					}
+				case *ssa.FieldAddr:
+					if mayBeInitialized(instr) {
+						initialized[fieldOf(instr)] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+
+	fs := make(map[*types.Var]struct{})
+	for f := range updated {
+		if f.Pkg() != pkg || f.Exported() {
+			continue
+		}
+		if _, ok := f.Type().Underlying().(*types.Map); !ok {
+			continue
+		}
+		if _, ok := initialized[f]; ok {
+			continue
+		}
+		fs[f] = struct{}{}
+	}
+	return fs
+}
+
+// mayBeInitialized reports whether a non-nil value may be
+// stored to the field through fa.
+func mayBeInitialized(fa *ssa.FieldAddr) bool {
+	rs := fa.Referrers()
+	if rs == nil {
+		return false
+	}
+	for _, r := range *rs {
+		switch r := r.(type) {
+		case *ssa.DebugRef:
+			continue
+		case *ssa.UnOp:
+			if r.Op == token.MUL {
+				continue
+			}
+		case *ssa.Store:
+			if c, ok := r.Val.(*ssa.Const); ok && r.Addr == fa && c.IsNil() {
+				continue
+			}
+		}
+		// A value is stored, or the address escapes.
+		return true
+	}
+	return false
+}
+
+// selectorAt returns the selector expression whose
+// selected identifier is at pos, or nil if not found.
+func selectorAt(files []*ast.File, pos token.Pos) *ast.SelectorExpr {
+	for _, f := range files {
+		if pos < f.Pos() || f.End() < pos {
+			continue
+		}
+		var sel *ast.SelectorExpr
+		ast.Inspect(f, func(n ast.Node) bool {
+			if sel != nil || n == nil || pos < n.Pos() || n.End() < pos {
+				return false
+			}
+			if se, ok := n.(*ast.SelectorExpr); ok && se.Sel.Pos() == pos {
+				sel = se
+				return false
+			}
+			return true
+		})
+		return sel
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package knil
+
+// This file contains processes for the suggested fixes
+// removing the dead branches of the degenerate nil
+// comparisons, such as
+//
+//	if ch != nil { ... }  →  { ... }
+//
+// The fixes are suggested only if the rewrite is
+// syntactically safe, i.e. the comparison is the whole
+// condition of an if statement without an init statement,
+// the compared operands are identifiers without side
+// effects, and the removed code has no labels or last uses
+// of variables or imports.
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ssa"
+)
+
+// fileOf returns the file of the package containing pos.
+func fileOf(pass *analysis.Pass, pos token.Pos) *ast.File {
+	for _, f := range pass.Files {
+		if f.Pos() <= pos && pos < f.End() {
+			return f
+		}
+	}
+	return nil
+}
+
+// condFixes returns the suggested fixes removing the dead
+// branch of the if statement whose condition is binop,
+// which is always true if tautological, or always false.
+func condFixes(pass *analysis.Pass, binop *ssa.BinOp, tautological bool) []analysis.SuggestedFix {
+	f := fileOf(pass, binop.Pos())
+	if f == nil {
+		return nil
+	}
+	path, _ := astutil.PathEnclosingInterval(f, binop.Pos(), binop.Pos())
+	if len(path) < 3 {
+		return nil
+	}
+	cond, ok := path[0].(*ast.BinaryExpr)
+	if !ok || cond.OpPos != binop.Pos() {
+		return nil
+	}
+	ifStmt, ok := path[1].(*ast.IfStmt)
+	if !ok || ifStmt.Cond != cond || ifStmt.Init != nil {
+		return nil
+	}
+	// The comparison is removed, and the operands
+	// such as calls would be no longer evaluated.
+	if !isIdent(cond.X) || !isIdent(cond.Y) {
+		return nil
+	}
+
+	var edits []analysis.TextEdit
+	var message string
+	del := func(pos, end token.Pos) {
+		edits = append(edits, analysis.TextEdit{Pos: pos, End: end})
+	}
+	switch {
+	case tautological:
+		// Keep the body as a block for the scope of it.
+		message = "remove the tautological condition"
+		del(ifStmt.Pos(), ifStmt.Body.Lbrace)
+		if ifStmt.Else != nil {
+			del(ifStmt.Body.End(), ifStmt.End())
+		}
+	case ifStmt.Else != nil:
+		// Keep the else branch as a block or an if statement.
+		message = "remove the impossible branch"
+		del(ifStmt.Pos(), ifStmt.Else.Pos())
+	default:
+		message = "remove the impossible branch"
+		start, ok := stmtStart(f, path[1:])
+		if !ok {
+			return nil
+		}
+		del(start, ifStmt.End())
+	}
+
+	if !safeToDelete(pass, f, edits) {
+		return nil
+	}
+	return []analysis.SuggestedFix{{Message: message, TextEdits: edits}}
+}
+
+// isIdent reports whether e is an identifier,
+// possibly in parentheses.
+func isIdent(e ast.Expr) bool {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.ParenExpr:
+		return isIdent(e.X)
+	}
+	return false
+}
+
+// stmtStart returns the position from which the statement
+// path[0] is deleted, including the line break and the
+// indentation before it unless there are comments.
+// It reports false if the statement can't be deleted.
+func stmtStart(f *ast.File, path []ast.Node) (token.Pos, bool) {
+	stmt := path[0]
+	var prev token.Pos
+	var list []ast.Stmt
+	switch p := path[1].(type) {
+	case *ast.BlockStmt:
+		prev, list = p.Lbrace+1, p.List
+	case *ast.CaseClause:
+		prev, list = p.Colon+1, p.Body
+	case *ast.CommClause:
+		prev, list = p.Colon+1, p.Body
+	case *ast.IfStmt:
+		// The else if statement is deleted with the else.
+		if p.Else != stmt {
+			return token.NoPos, false
+		}
+		return p.Body.End(), true
+	default:
+		// Such as a labeled statement.
+		return token.NoPos, false
+	}
+	for i, s := range list {
+		if s == stmt && i > 0 {
+			prev = list[i-1].End()
+		}
+	}
+	for _, cg := range f.Comments {
+		if prev <= cg.Pos() && cg.End() <= stmt.Pos() {
+			return stmt.Pos(), true
+		}
+	}
+	return prev, true
+}
+
+// safeToDelete reports whether deleting the code in the
+// edits keeps the package buildable, i.e. the code has no
+// labels and no last uses of local variables or imports.
+func safeToDelete(pass *analysis.Pass, f *ast.File, edits []analysis.TextEdit) bool {
+	deleted := func(pos token.Pos) bool {
+		for _, e := range edits {
+			if e.Pos <= pos && pos < e.End {
+				return true
+			}
+		}
+		return false
+	}
+
+	safe := true
+	ast.Inspect(f, func(n ast.Node) bool {
+		if _, ok := n.(*ast.LabeledStmt); ok && deleted(n.Pos()) {
+			safe = false
+		}
+		return safe
+	})
+	if !safe {
+		return false
+	}
+
+	// The objects used in the deleted code.
+	used := make(map[types.Object]bool)
+	for id, obj := range pass.TypesInfo.Uses {
+		if id.Pos() < f.Pos() || f.End() <= id.Pos() || !deleted(id.Pos()) {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.PkgName:
+			used[obj] = false
+		case *types.Var:
+			if !obj.IsField() && obj.Parent() != nil && obj.Parent() != obj.Pkg().Scope() {
+				used[obj] = false
+			}
+		}
+	}
+	// Whether they are still used after the deletion.
+	for id, obj := range pass.TypesInfo.Uses {
+		if _, ok := used[obj]; ok && f.Pos() <= id.Pos() && id.Pos() < f.End() && !deleted(id.Pos()) {
+			used[obj] = true
+		}
+	}
+	for _, ok := range used {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
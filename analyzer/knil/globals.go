@@ -0,0 +1,159 @@
+package knil
+
+// This file contains processes for inferring the nilness of
+// global variables from all the stores to them in the package.
+// Unexported global variables set only to non-nil values in the
+// package initializer or init functions are non-nil after the
+// package is initialized, unless they are changed concurrently.
+// Exported ones may be set to nil by importing packages.
+// The states of exported global variables are exported as
+// facts, and importing packages consult them before reporting
+// dereferences of the global variables.
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+// globalStates returns the states of the global variables of
+// pkg and the ones referred in fns, inferred from the stores
+// to them in fns and the facts of the global variables in
+// other packages. The states of the global variables in other
+// packages without facts are omitted.
+func globalStates(pass *analysis.Pass, pkg *ssa.Package, fns []*ssa.Function) map[*ssa.Global]globalState {
+	type stores struct {
+		// inInit is whether the global variable is
+		// set in the package initialization.
+		inInit bool
+		// outOfInit is whether the global variable is
+		// set after the package initialization.
+		outOfInit bool
+		// mayBeNil is whether values which may be nil
+		// are stored to the global variable.
+		mayBeNil bool
+		// escaped is whether the address of the global
+		// variable escapes.
+		escaped bool
+	}
+	gss := make(map[*ssa.Global]*stores)
+	for _, m := range pkg.Members {
+		if g, ok := m.(*ssa.Global); ok && g.Object() != nil {
+			gss[g] = &stores{}
+		}
+	}
+	for _, fn := range fns {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				var rands [10]*ssa.Value
+				for _, rand := range instr.Operands(rands[:0]) {
+					g, ok := (*rand).(*ssa.Global)
+					if !ok || g.Object() == nil {
+						continue
+					}
+					gs, ok := gss[g]
+					if !ok {
+						gs = &stores{}
+						gss[g] = gs
+					}
+					switch instr := instr.(type) {
+					case *ssa.UnOp:
+						continue
+					case *ssa.Store:
+						if instr.Addr == g {
+							if storedNilness(pass, instr.Val) != isnonnil {
+								gs.mayBeNil = true
+							}
+							if isInitFunc(fn) && g.Pkg.Pkg == pass.Pkg {
+								gs.inInit = true
+							} else {
+								gs.outOfInit = true
+							}
+							continue
+						}
+					}
+					gs.escaped = true
+				}
+			}
+		}
+	}
+
+	states := make(map[*ssa.Global]globalState)
+	for g, gs := range gss {
+		if g.Pkg.Pkg != pass.Pkg {
+			// Stores in this package override
+			// the facts of other packages.
+			if gs.escaped || gs.outOfInit {
+				states[g] = globalMayBeNil
+				continue
+			}
+			gi := globalInfo{}
+			if pass.ImportObjectFact(g.Object(), &gi) {
+				states[g] = gi.state
+			}
+			continue
+		}
+		switch {
+		case gs.escaped:
+			states[g] = globalMayBeNil
+		case gs.mayBeNil && gs.outOfInit:
+			states[g] = globalMayBeNil
+		case gs.mayBeNil, !gs.inInit && !gs.outOfInit:
+			states[g] = globalInitOnly
+		case gs.inInit && g.Object().Exported():
+			// Importing packages may set exported
+			// global variables to nil.
+			states[g] = globalInitOnly
+		case gs.inInit:
+			states[g] = globalNeverNil
+		default:
+			// Only non-nil values are stored after the
+			// initialization, but it is nil before that.
+			states[g] = globalMayBeNil
+		}
+	}
+	return states
+}
+
+// storedNilness returns the nilness of the value v stored to
+// a global variable, using the return values of static callees.
+func storedNilness(pass *analysis.Pass, v ssa.Value) nilness {
+	if n := nilnessOf(nil, v); n != unknown {
+		return n
+	}
+	c, ok := v.(*ssa.Call)
+	if !ok {
+		return unknown
+	}
+	s := c.Call.StaticCallee()
+	if s == nil || s.Object() == nil {
+		return unknown
+	}
+	fi := functionInfo{}
+	pass.ImportObjectFact(s.Object(), &fi)
+	if fi.nr.length() != 1 {
+		return unknown
+	}
+	return mergePosToNilnesses(fi.nr)[0]
+}
+
+// exportGlobalFacts exports the states of the exported
+// global variables of the package as facts.
+func exportGlobalFacts(pass *analysis.Pass, states map[*ssa.Global]globalState) {
+	for g, s := range states {
+		if g.Pkg.Pkg != pass.Pkg || !g.Object().Exported() {
+			continue
+		}
+		pass.ExportObjectFact(g.Object(), &globalInfo{s})
+	}
+}
+
+// isInitFunc reports whether fn is the package initializer
+// or an init function, which run before any other functions.
+func isInitFunc(fn *ssa.Function) bool {
+	if fn.Parent() != nil || fn.Signature.Recv() != nil {
+		return false
+	}
+	return fn.Name() == "init" || strings.HasPrefix(fn.Name(), "init#")
+}
@@ -0,0 +1,276 @@
+package knil
+
+// This file contains processes for the suggested fixes
+// inserting early nil guards of the dereferenced parameters
+// and call results, such as
+//
+//	func f(p *T) (int, error) {
+//		if p == nil {
+//			return 0, errors.New("p is nil")
+//		}
+//		...
+//
+// which return the zero values of the results of the
+// enclosing function, and an error if the last result is an
+// error. The guards of the call results are inserted before
+// the statements dereferencing them, after the checks of the
+// errors of the calls. No guard is suggested if an error is
+// returned and the file imports neither errors nor fmt.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ssa"
+)
+
+// fixGuards is whether the fixes inserting
+// nil guards are suggested.
+var fixGuards bool
+
+// guardFixes returns the suggested fixes inserting a nil guard
+// of v, a parameter of fn or a result of a call in fn, which is
+// dereferenced at pos, or nil if the guard can't be inserted.
+func guardFixes(pass *analysis.Pass, fn *ssa.Function, v ssa.Value, pos token.Pos) []analysis.SuggestedFix {
+	if !fixGuards {
+		return nil
+	}
+	var body *ast.BlockStmt
+	switch s := fn.Syntax().(type) {
+	case *ast.FuncDecl:
+		body = s.Body
+	case *ast.FuncLit:
+		body = s.Body
+	}
+	if body == nil {
+		return nil
+	}
+	f := fileOf(pass, body.Pos())
+	if f == nil {
+		return nil
+	}
+
+	var name, format string
+	var at token.Pos
+	switch v := v.(type) {
+	case *ssa.Parameter:
+		if v.Object() == nil {
+			return nil
+		}
+		// The semicolon separates the guard from the statement
+		// following on the same line, and is removed by gofmt.
+		name, at, format = v.Object().Name(), body.Lbrace+1, "\nif %s == nil {\n%s\n};"
+	case *ssa.Call:
+		name, at = assignedName(f, v, 0, pos)
+	case *ssa.Extract:
+		if c, ok := v.Tuple.(*ssa.Call); ok {
+			name, at = assignedName(f, c, v.Index, pos)
+		}
+	}
+	if name == "" || name == "_" || !at.IsValid() {
+		return nil
+	}
+	if format == "" {
+		format = "if %s == nil {\n%s\n}\n"
+	}
+
+	ret, ok := zeroReturn(pass, f, fn.Signature, name)
+	if !ok {
+		return nil
+	}
+	return []analysis.SuggestedFix{{
+		Message: "insert a nil guard of " + name,
+		TextEdits: []analysis.TextEdit{{
+			Pos:     at,
+			End:     at,
+			NewText: []byte(fmt.Sprintf(format, name, ret)),
+		}},
+	}}
+}
+
+// assignedName returns the name of the variable which the
+// result of the call c at index is assigned to, and the start
+// of the statement dereferencing it at pos, where the guard is
+// inserted. The statement follows the assignment in the block.
+func assignedName(f *ast.File, c *ssa.Call, index int, pos token.Pos) (string, token.Pos) {
+	path, _ := astutil.PathEnclosingInterval(f, c.Pos(), c.Pos())
+	for i, n := range path {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || call.Lparen != c.Pos() {
+			continue
+		}
+		// j is the index of the statement in the path.
+		var j int
+		var lhs []ast.Expr
+		switch s := path[i+1].(type) {
+		case *ast.AssignStmt:
+			if len(s.Rhs) != 1 || (s.Tok != token.DEFINE && s.Tok != token.ASSIGN) {
+				return "", token.NoPos
+			}
+			j, lhs = i+1, s.Lhs
+		case *ast.ValueSpec:
+			if d, ok := path[i+2].(*ast.GenDecl); !ok || len(d.Specs) != 1 || len(s.Values) != 1 {
+				return "", token.NoPos
+			}
+			j = i + 3
+			for _, id := range s.Names {
+				lhs = append(lhs, id)
+			}
+		default:
+			return "", token.NoPos
+		}
+		// The guard is inserted in the block of the statement.
+		if j+1 >= len(path) {
+			return "", token.NoPos
+		}
+		switch path[j+1].(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+		default:
+			return "", token.NoPos
+		}
+		if index >= len(lhs) {
+			return "", token.NoPos
+		}
+		id, ok := lhs[index].(*ast.Ident)
+		if !ok {
+			return "", token.NoPos
+		}
+		s := stmtIn(f, path[j+1], pos)
+		if s == nil || s.Pos() < path[j].End() {
+			return "", token.NoPos
+		}
+		return id.Name, s.Pos()
+	}
+	return "", token.NoPos
+}
+
+// stmtIn returns the statement directly in block
+// which contains pos, or nil if there is none.
+func stmtIn(f *ast.File, block ast.Node, pos token.Pos) ast.Stmt {
+	path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+	for i := 0; i+1 < len(path); i++ {
+		if path[i+1] == block {
+			s, _ := path[i].(ast.Stmt)
+			return s
+		}
+	}
+	return nil
+}
+
+// zeroReturn returns the return statement of the zero values
+// of the results of sig, with an error describing that name is
+// nil if the last result is an error. It reports false if the
+// zero values can't be written in the file f.
+func zeroReturn(pass *analysis.Pass, f *ast.File, sig *types.Signature, name string) (string, bool) {
+	ok := true
+	qualifier := func(p *types.Package) string {
+		if p == pass.Pkg {
+			return ""
+		}
+		if n, imported := importName(f, p.Path()); imported {
+			switch n {
+			case "":
+				return p.Name()
+			case ".":
+				return ""
+			}
+			return n
+		}
+		ok = false
+		return p.Name()
+	}
+
+	rs := sig.Results()
+	if rs.Len() == 0 {
+		return "return", true
+	}
+	vals := make([]string, rs.Len())
+	for i := 0; i < rs.Len(); i++ {
+		t := rs.At(i).Type()
+		if i == rs.Len()-1 && types.Identical(t, errorType) {
+			// Returning nil would turn the nil into a success.
+			e, eok := nilError(f, name)
+			if !eok {
+				return "", false
+			}
+			vals[i] = e
+			continue
+		}
+		z, zok := zeroValue(pass, t, qualifier)
+		if !zok {
+			return "", false
+		}
+		vals[i] = z
+	}
+	return "return " + strings.Join(vals, ", "), ok
+}
+
+// zeroValue returns the expression of the zero value of t.
+func zeroValue(pass *analysis.Pass, t types.Type, qualifier types.Qualifier) (string, bool) {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", true
+		case u.Info()&types.IsNumeric != 0:
+			return "0", true
+		case u.Info()&types.IsString != 0:
+			return `""`, true
+		case u.Kind() == types.UnsafePointer:
+			return "nil", true
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil", true
+	case *types.Struct, *types.Array:
+		if n, ok := t.(*types.Named); ok && n.Obj().Pkg() != pass.Pkg && !n.Obj().Exported() {
+			return "", false
+		}
+		return types.TypeString(t, qualifier) + "{}", true
+	}
+	return "", false
+}
+
+// nilError returns the expression of an error describing
+// that name is nil. It reports false if the file imports
+// neither errors nor fmt.
+func nilError(f *ast.File, name string) (string, bool) {
+	msg := strconv.Quote(name + " is nil")
+	for _, pkg := range []string{"errors", "fmt"} {
+		n, ok := importName(f, pkg)
+		if !ok || n == "." {
+			continue
+		}
+		if n == "" {
+			n = pkg
+		}
+		if pkg == "errors" {
+			return n + ".New(" + msg + ")", true
+		}
+		return n + ".Errorf(" + msg + ")", true
+	}
+	return "", false
+}
+
+// importName returns the name of the import of the package
+// of path in the file f, or empty if the import is unnamed.
+// It reports false if the package is not imported or the
+// import is blank.
+func importName(f *ast.File, path string) (string, bool) {
+	for _, imp := range f.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || p != path {
+			continue
+		}
+		if imp.Name == nil {
+			return "", true
+		}
+		return imp.Name.Name, imp.Name.Name != "_"
+	}
+	return "", false
+}
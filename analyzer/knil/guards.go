@@ -0,0 +1,252 @@
+package knil
+
+// This file contains processes for guard functions, such as
+//
+//	func must(v interface{}) {
+//		if v == nil {
+//			panic("nil")
+//		}
+//	}
+//
+// which panic or exit unless the arguments are non-nil or
+// true, so the arguments are known to be non-nil or true
+// after the calls return. Guards are inferred from the
+// functions in the package and exported as facts, and the
+// ones which can't be inferred are listed in the config.
+
+import (
+	"go/token"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+// calleeGuards returns the guards of the parameters of the
+// callee of c aligned to the arguments, or nil if none.
+func calleeGuards(pass *analysis.Pass, cfg *config, c *ssa.CallCommon) guardKinds {
+	if is, ok := cfg.guards[calleeName(c)]; ok {
+		ks := make(guardKinds, len(c.Args))
+		for i, arg := range c.Args {
+			if is != nil {
+				continue
+			}
+			if isNillable(arg.Type()) {
+				ks[i] = guardedNonnil
+			} else if isBool(arg.Type()) {
+				ks[i] = guardedTrue
+			}
+		}
+		for _, i := range is {
+			if i < len(ks) {
+				ks[i] = guardedNonnil
+			}
+		}
+		return ks
+	}
+	s := c.StaticCallee()
+	if s == nil || s.Object() == nil {
+		return nil
+	}
+	gi := guardInfo{}
+	if !pass.ImportObjectFact(s.Object(), &gi) {
+		return nil
+	}
+	// Bound method closures take the receiver as a free variable.
+	if len(gi.params) == len(c.Args)+1 {
+		return gi.params[1:]
+	}
+	if len(gi.params) != len(c.Args) {
+		return nil
+	}
+	return gi.params
+}
+
+// guardFacts returns the facts established after instr
+// if it is a call to a guard function.
+func guardFacts(pass *analysis.Pass, cfg *config, instr ssa.Instruction) []nilnessOfValue {
+	call, ok := instr.(ssa.CallInstruction)
+	if !ok {
+		return nil
+	}
+	// Arguments of interface parameters are converted to
+	// interfaces, which are non-nil even if the converted
+	// values are nil pointers. Only the assertions checking
+	// them with reflection tell the converted values are
+	// non-nil.
+	_, reflective := testGuards[calleeName(call.Common())]
+	var fs []nilnessOfValue
+	for i, k := range calleeGuards(pass, cfg, call.Common()) {
+		arg := call.Common().Args[i]
+		switch k {
+		case guardedNonnil:
+			if mi, ok := arg.(*ssa.MakeInterface); ok && reflective {
+				arg = mi.X
+			}
+			if isNillable(arg.Type()) {
+				fs = append(fs, nilnessOfValue{arg, isnonnil})
+			}
+		case guardedTrue:
+			if f, ok := condFact(arg); ok {
+				fs = append(fs, f)
+			}
+		}
+	}
+	return fs
+}
+
+// condFact returns the fact established if the
+// condition cond is true, such as x != nil.
+func condFact(cond ssa.Value) (nilnessOfValue, bool) {
+	switch cond := cond.(type) {
+	case *ssa.UnOp:
+		if cond.Op == token.NOT {
+			f, ok := condFact(cond.X)
+			return f.negate(), ok
+		}
+	case *ssa.BinOp:
+		var v ssa.Value
+		if isNilConst(cond.X) {
+			v = cond.Y
+		} else if isNilConst(cond.Y) {
+			v = cond.X
+		} else {
+			return nilnessOfValue{}, false
+		}
+		switch cond.Op {
+		case token.EQL:
+			return nilnessOfValue{v, isnil}, true
+		case token.NEQ:
+			return nilnessOfValue{v, isnonnil}, true
+		}
+	}
+	return nilnessOfValue{}, false
+}
+
+func isNilConst(v ssa.Value) bool {
+	c, ok := v.(*ssa.Const)
+	return ok && c.IsNil()
+}
+
+func isBool(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsBoolean != 0
+}
+
+// exportGuards infers the guards of fns and exports them
+// as facts, until the guards calling others converge.
+func exportGuards(pass *analysis.Pass, cfg *config, fns []*ssa.Function) {
+	for updated := true; updated; {
+		updated = false
+		for _, fn := range fns {
+			if fn.Object() == nil || fn.Blocks == nil {
+				continue
+			}
+			ks := inferGuards(pass, cfg, fn)
+			if ks == nil {
+				continue
+			}
+			gi := guardInfo{}
+			if pass.ImportObjectFact(fn.Object(), &gi) && reflect.DeepEqual(gi.params, ks) {
+				continue
+			}
+			pass.ExportObjectFact(fn.Object(), &guardInfo{ks})
+			updated = true
+		}
+	}
+}
+
+// inferGuards returns the guards of the parameters of fn,
+// or nil if none. A parameter is guarded if all the returns
+// are dominated by the blocks where it is non-nil or true.
+func inferGuards(pass *analysis.Pass, cfg *config, fn *ssa.Function) guardKinds {
+	var returns []*ssa.BasicBlock
+	for _, b := range fn.Blocks {
+		if _, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return); ok && !cfg.isDead(b) {
+			returns = append(returns, b)
+		}
+	}
+	// Functions which never return are not guards.
+	if len(returns) == 0 {
+		return nil
+	}
+	ks := make(guardKinds, len(fn.Params))
+	found := false
+	for i, p := range fn.Params {
+		var k guardKind
+		switch {
+		case isNillable(p.Type()):
+			k = guardedNonnil
+		case isBool(p.Type()):
+			k = guardedTrue
+		default:
+			continue
+		}
+		guarded := true
+		for _, r := range returns {
+			if !isGuardedAt(pass, cfg, r, p, k) {
+				guarded = false
+				break
+			}
+		}
+		if guarded {
+			ks[i] = k
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return ks
+}
+
+// isGuardedAt reports whether p is guarded by k in b,
+// which is dominated by a successor of a nil check or a
+// condition of p, or a call to another guard.
+func isGuardedAt(pass *analysis.Pass, cfg *config, b *ssa.BasicBlock, p *ssa.Parameter, k guardKind) bool {
+	for d := b; d != nil; d = d.Idom() {
+		for _, instr := range d.Instrs {
+			for _, f := range guardFacts(pass, cfg, instr) {
+				if f.value == p && (k == guardedNonnil && f.nilness == isnonnil) {
+					return true
+				}
+			}
+			if call, ok := instr.(ssa.CallInstruction); ok && k == guardedTrue {
+				for i, gk := range calleeGuards(pass, cfg, call.Common()) {
+					if gk == guardedTrue && call.Common().Args[i] == ssa.Value(p) {
+						return true
+					}
+				}
+			}
+		}
+
+		preds := cfg.livePreds(d)
+		if len(preds) != 1 {
+			continue
+		}
+		If, ok := preds[0].Instrs[len(preds[0].Instrs)-1].(*ssa.If)
+		if !ok {
+			continue
+		}
+		switch k {
+		case guardedNonnil:
+			// The condition is true in the first successor.
+			f, ok := condFact(If.Cond)
+			if !ok || f.value != ssa.Value(p) {
+				continue
+			}
+			if d == preds[0].Succs[1] {
+				f = f.negate()
+			}
+			if f.nilness == isnonnil {
+				return true
+			}
+		case guardedTrue:
+			if If.Cond == ssa.Value(p) && d == preds[0].Succs[0] {
+				return true
+			}
+		}
+	}
+	return false
+}
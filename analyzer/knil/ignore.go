@@ -13,8 +13,8 @@ import (
 
 var ignoreFilesRegexp = `.*_test.go|zz_generated.*`
 
-func isIgnoredFunction(f *ssa.Function) bool {
-	m, err := regexp.MatchString(ignoreFilesRegexp, getFileNameOfFunction(f))
+func isIgnoredFile(name string) bool {
+	m, err := regexp.MatchString(ignoreFilesRegexp, name)
 	if err != nil {
 		panic(err)
 	}
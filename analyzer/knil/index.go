@@ -0,0 +1,61 @@
+package knil
+
+// This file contains processes for index operations on
+// nil slices, which panic with index out of range.
+
+import (
+	"go/token"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// isBoundsChecked reports whether the index of instr is
+// dominated by the comparison with the length of the
+// slice, such as loop conditions of range statements.
+func isBoundsChecked(instr *ssa.IndexAddr) bool {
+	b := instr.Block()
+	for d := b.Idom(); d != nil; d = d.Idom() {
+		If, ok := d.Instrs[len(d.Instrs)-1].(*ssa.If)
+		if !ok {
+			continue
+		}
+		binop, ok := If.Cond.(*ssa.BinOp)
+		if !ok {
+			continue
+		}
+		var length ssa.Value
+		switch binop.Op {
+		case token.LSS, token.LEQ:
+			// i < len(s)
+			length = binop.Y
+		case token.GTR, token.GEQ:
+			// len(s) > i
+			length = binop.X
+		default:
+			continue
+		}
+		if isLenOf(length, instr.X) && d.Succs[0].Dominates(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLenOf reports whether v is the length of s.
+func isLenOf(v, s ssa.Value) bool {
+	c, ok := v.(*ssa.Call)
+	if !ok {
+		return false
+	}
+	if b, ok := c.Call.Value.(*ssa.Builtin); !ok || b.Name() != "len" {
+		return false
+	}
+	arg := c.Call.Args[0]
+	if arg == s {
+		return true
+	}
+	// Each nil constant is a distinct value.
+	ac, ok := arg.(*ssa.Const)
+	sc, ok2 := s.(*ssa.Const)
+	return ok && ok2 && ac.IsNil() && sc.IsNil()
+}
@@ -28,21 +28,86 @@ var Analyzer = &analysis.Analyzer{
 	Doc:       doc,
 	Run:       run,
 	Requires:  []*analysis.Analyzer{buildssa.Analyzer},
-	FactTypes: []analysis.Fact{new(functionInfo), new(pkgDone), new(alreadyReportedGlobal)},
+	FactTypes: []analysis.Fact{new(functionInfo), new(pkgDone), new(alreadyReportedGlobal), new(globalInfo), new(contractInfo), new(guardInfo)},
+}
+
+// concurrent is whether global variables can be
+// set to nil concurrently at any time.
+var concurrent boolFlag
+
+func init() {
+	Analyzer.Flags.Var(&concurrent, "concurrent",
+		"assume that global variables can be set to nil concurrently at any time")
+	Analyzer.Flags.StringVar(&contractFiles, "contracts", "",
+		"comma-separated list of external contract files")
+	Analyzer.Flags.BoolVar(&reportUnusedIgnores, "report-unused-ignores", false,
+		"report //knil:ignore and //nolint:knil directives which suppress nothing")
+	Analyzer.Flags.StringVar(&configFile, "config", "",
+		"configuration file, .knil.yaml or .knil.json in the module root by default")
+	Analyzer.Flags.StringVar(&enabledCategories, "categories", "",
+		"comma-separated list of the enabled categories, all by default")
+	Analyzer.Flags.Var(&analyzeTests, "tests",
+		"analyze the test files")
+	Analyzer.Flags.StringVar(&minSeverity, "min-severity", "",
+		`minimum severity of the reported diagnostics, "info" (default), "warning" or "error"`)
+	Analyzer.Flags.StringVar(&exportedParams, "exported-params", "",
+		`policy for the parameters of exported functions, "application" (default, only the callers in the same package), "library" or "trusting"`)
+	Analyzer.Flags.BoolVar(&fixGuards, "fix-guards", false,
+		"suggest fixes inserting early nil guards of the dereferenced parameters and call results")
+	Analyzer.Flags.StringVar(&whyPosition, "why", "",
+		"report the inferred nilness of the values at the position file.go:LINE:COL with the facts leading to it")
 }
 
 func run(pass *analysis.Pass) (interface{}, error) {
 	ssainput := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
-	alreadyReported := make(map[ssa.Instruction]struct{})
+	if isTestMain(pass) {
+		return nil, nil
+	}
+	cfg, err := loadConfig(pass)
+	if err != nil {
+		return nil, err
+	}
+	why, err := newWhyQuery(pass)
+	if err != nil {
+		return nil, err
+	}
+	pass = withRanges(cfg.filterReports(pass))
+	suppressions := parseSuppressions(pass)
+	// The unused suppressions are reported with the
+	// pass before dropping the suppressed diagnostics.
+	unsuppressed := pass
+	pass = suppressions.filterReports(pass)
+	if err := loadContractFiles(cfg.Contracts); err != nil {
+		return nil, err
+	}
+	if err := checkContractFiles(pass); err != nil {
+		return nil, err
+	}
+	exportContracts(pass)
+	fns := packageFuncs(ssainput)
+	exportGuards(pass, cfg, fns)
+	st := &state{
+		alreadyReported:   make(map[ssa.Instruction]struct{}),
+		uninitializedMaps: uninitializedMapFields(pass.Pkg, fns),
+		reportedGlobals:   make(map[*ssa.Global]struct{}),
+		suppressions:      suppressions,
+		config:            cfg,
+		why:               why,
+	}
 	for true {
 		updated := false
+		// The nilness of global variables depends on
+		// the return values of the functions.
+		if !cfg.Concurrent {
+			st.globals = globalStates(pass, ssainput.Pkg, fns)
+		}
 		for _, fn := range ssainput.SrcFuncs {
 			// TODO(Matts966): ignore these cases in the new driver.
-			if isIgnoredFunction(fn) {
+			if cfg.isIgnoredFunction(fn) {
 				continue
 			}
 
-			if checkFunc(pass, fn, true, alreadyReported) {
+			if checkFunc(pass, fn, true, st) {
 				fi := functionInfo{}
 				if fn.Object() != nil {
 					pass.ImportObjectFact(fn.Object(), &fi)
@@ -59,19 +124,57 @@ func run(pass *analysis.Pass) (interface{}, error) {
 	// We should create it in the golang.org/x/tools because some required tools
 	// are in internal packages. Also we can't rely on facts of standard packages
 	// in some drivers such as Bazel and Blaze.
+	exportGlobalFacts(pass, st.globals)
 	pass.ExportPackageFact(&pkgDone{})
+	if cfg.isIgnoredPackage(pass.Pkg.Path()) {
+		return nil, nil
+	}
 	for _, fn := range ssainput.SrcFuncs {
 
 		// TODO(Matts966): handle these cases in the new driver.
-		if isIgnoredFunction(fn) {
+		if cfg.isIgnoredFunction(fn) {
 			continue
 		}
 
-		checkFunc(pass, fn, false, alreadyReported)
+		checkFunc(pass, fn, false, st)
+	}
+	st.why.reportNotFound()
+	if reportUnusedIgnores {
+		st.suppressions.reportUnused(unsuppressed)
 	}
 	return nil, nil
 }
 
+// state holds the information about the package
+// shared by the checks of its functions.
+type state struct {
+	// alreadyReported holds the instructions whose
+	// root causes are already reported.
+	alreadyReported map[ssa.Instruction]struct{}
+
+	// uninitializedMaps holds the map fields which
+	// are updated but never initialized in the package.
+	uninitializedMaps map[*types.Var]struct{}
+
+	// globals holds the states of the global variables
+	// after the package initialization.
+	globals map[*ssa.Global]globalState
+
+	// reportedGlobals holds the global variables in other
+	// packages whose root causes are already reported.
+	reportedGlobals map[*ssa.Global]struct{}
+
+	// suppressions holds the directives suppressing
+	// the diagnostics.
+	suppressions *suppressions
+
+	config *config
+
+	// why is the query of the nilness at a position,
+	// or nil if there is no query in the package.
+	why *whyQuery
+}
+
 // checkFunc checks all the function calls with nil
 // parameters and export their information as ObjectFact,
 // and returns whether the fact is updated.
@@ -79,20 +182,39 @@ func run(pass *analysis.Pass) (interface{}, error) {
 // exports facts.
 // Diagnostics are emitted using the facts if onlyCheck is false.
 //
-func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyReported map[ssa.Instruction]struct{}) bool {
+func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, st *state) bool {
 	bs := fn.Blocks
 	if bs == nil {
 		return false
 	}
 
+	report := pass.Report
 	reportf := func(category string, pos token.Pos, format string, args ...interface{}) {
-		pass.Report(analysis.Diagnostic{
+		report(analysis.Diagnostic{
 			Pos:      pos,
 			Category: category,
 			Message:  fmt.Sprintf(format, args...),
 		})
 	}
 
+	// origs holds the origins of the facts for explaining diagnostics.
+	origs := origins{}
+
+	// guarded returns the stack with the facts established
+	// by the guard call instr. The facts precede the stack
+	// like the implied facts of contracts because the
+	// results of calls are already on it as unknown.
+	guarded := func(stack []nilnessOfValue, instr ssa.Instruction) []nilnessOfValue {
+		fs := guardFacts(pass, st.config, instr)
+		if len(fs) == 0 {
+			return stack
+		}
+		for _, f := range fs {
+			origs.record(f, instr.Pos(), "%s after this call")
+		}
+		return append(fs, stack...)
+	}
+
 	type visitor func(b *ssa.BasicBlock, stack []nilnessOfValue)
 
 	prune := func(b *ssa.BasicBlock, stack []nilnessOfValue, visit visitor) bool {
@@ -114,7 +236,19 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 					} else {
 						adj = "impossible"
 					}
-					reportf("cond", binop.Pos(), "%s condition: %s %s %s", adj, xnil, binop.Op, ynil)
+					var related []analysis.RelatedInformation
+					for _, v := range []ssa.Value{binop.X, binop.Y} {
+						if !isNilConst(v) {
+							related = append(related, explain(pass, fn, stack, origs, v)...)
+						}
+					}
+					report(analysis.Diagnostic{
+						Pos:            binop.Pos(),
+						Category:       "cond",
+						Message:        fmt.Sprintf("%s condition: %s %s %s", adj, xnil, binop.Op, ynil),
+						Related:        related,
+						SuggestedFixes: condFixes(pass, binop, adj == "tautological"),
+					})
 				}
 
 				// If tsucc's or fsucc's sole incoming edge is impossible,
@@ -149,17 +283,19 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 					// t successor learns x is nil.
 					f = nilnessOfValue{binop.X, isnil}
 				}
+				origs.record(f, binop.Pos(), "%s by this comparison")
+				origs.record(f.negate(), binop.Pos(), "%s by this comparison")
 				for _, d := range b.Dominees() {
 					// Successor blocks learn a fact
 					// only at non-critical edges.
 					// (We could do be more precise with full dataflow
 					// analysis of control-flow joins.)
 					s := stack
-					if len(d.Preds) == 1 {
+					if len(st.config.livePreds(d)) == 1 {
 						if d == tsucc {
-							s = append(s, f)
+							s = withImpliedFacts(pass, s, f)
 						} else if d == fsucc {
-							s = append(s, f.negate())
+							s = withImpliedFacts(pass, s, f.negate())
 						}
 					}
 					visit(d, s)
@@ -173,6 +309,69 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 	generateStackFromKnownFacts := func(fo types.Object) []nilnessOfValue {
 		pa := functionInfo{}
 		stack := make([]nilnessOfValue, 0, 20) // 20 is plenty
+
+		// The testing package passes non-nil values to tests.
+		stack = append(stack, testParamFacts(fn)...)
+
+		// Contracts precede the facts inferred from the callers.
+		if ci, ok := contractOf(pass, fo); ok && len(ci.params) == len(fn.Params) {
+			for i, a := range ci.params {
+				if a != notAnnotated {
+					stack = append(stack, nilnessOfValue{fn.Params[i], a.nilness()})
+				}
+			}
+		}
+
+		for _, b := range bs {
+			for _, instr := range b.Instrs {
+				v, ok := instr.(ssa.Value)
+				if !ok {
+					continue
+				}
+				if f := loadedField(v); f != nil {
+					// Fields have the nilness of their contracts.
+					if ci, ok := contractOf(pass, f); ok {
+						stack = append(stack, nilnessOfValue{v, ci.field.nilness()})
+					}
+					// Map fields never initialized in the package are nil.
+					if _, ok := st.uninitializedMaps[f]; ok {
+						stack = append(stack, nilnessOfValue{v, isnil})
+					}
+				}
+				// Global variables never nil after the initialization
+				// are non-nil except in the initialization.
+				if u, ok := v.(*ssa.UnOp); ok {
+					if g, ok := u.X.(*ssa.Global); ok && st.globals[g] == globalNeverNil {
+						if g.Pkg.Pkg != pass.Pkg || !isInitFunc(fn) {
+							stack = append(stack, nilnessOfValue{v, isnonnil})
+						}
+					}
+				}
+			}
+		}
+
+		// With the application policy, the parameters of exported
+		// functions also have the facts of the callers in the
+		// package only, as the unexported ones.
+		if fo != nil && fo.Exported() {
+			switch st.config.ExportedParams {
+			case policyLibrary:
+				// Any caller in other packages may pass nil.
+				return stack
+			case policyTrusting:
+				// Parameters are non-nil unless annotated nilable.
+				ci, _ := contractOf(pass, fo)
+				for i, p := range fn.Params {
+					if i < len(ci.params) && ci.params[i] == annotatedNilable {
+						continue
+					}
+					if isNillable(p.Type()) {
+						stack = append(stack, nilnessOfValue{p, isnonnil})
+					}
+				}
+				return stack
+			}
+		}
 		if fo != nil {
 			pass.ImportObjectFact(fo, &pa)
 		}
@@ -214,7 +413,11 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 			}
 			seen[b.Index] = true
 
-			for _, instr := range b.Instrs {
+			for i, instr := range b.Instrs {
+				// Arguments of guard calls are non-nil after the calls.
+				if i > 0 {
+					stack = guarded(stack, b.Instrs[i-1])
+				}
 				switch instr := instr.(type) {
 				case *ssa.Return:
 					fi := functionInfo{}
@@ -256,12 +459,13 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 						}
 						fi := functionInfo{}
 						pass.ImportObjectFact(f, &fi)
-						switch fi.nr.length() {
+						merged := applyResultContract(pass, c, mergePosToNilnesses(fi.nr))
+						switch len(merged) {
 						case 0:
 							continue
 						case 1:
 							if v, ok := instr.(ssa.Value); ok {
-								stack = append(stack, nilnessOfValue{v, mergePosToNilnesses(fi.nr)[0]})
+								stack = append(stack, nilnessOfValue{v, merged[0]})
 							}
 							continue
 						default:
@@ -270,12 +474,9 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 								if vrs == nil {
 									continue
 								}
-								c := 0
-								merged := mergePosToNilnesses(fi.nr)
 								for _, vr := range *vrs {
 									if e, ok := vr.(*ssa.Extract); ok {
-										stack = append(stack, nilnessOfValue{e, merged[c]})
-										c++
+										stack = append(stack, nilnessOfValue{e, merged[e.Index]})
 									}
 								}
 							}
@@ -375,12 +576,34 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 
 	// onlyCheck is false, emit diagnostics
 
-	// notNil reports an error if v can be nil.
-	notNil := func(stack []nilnessOfValue, instr ssa.Instruction, v ssa.Value, descr string) {
-		if nilnessOf(stack, v) == isnonnil {
+	// guardedValues holds the values whose nil
+	// guards are already suggested.
+	guardedValues := make(map[ssa.Value]struct{})
+
+	// notNilf reports an error with the formatted message if v can be nil.
+	notNilf := func(stack []nilnessOfValue, instr ssa.Instruction, v ssa.Value, format string, args ...interface{}) {
+		switch n := nilnessOf(stack, v); n {
+		case isnonnil:
 			return
+		default:
+			d := analysis.Diagnostic{
+				Pos:      instr.Pos(),
+				Category: "nilderef",
+				Message:  fmt.Sprintf(format, args...),
+				Related:  explain(pass, fn, stack, origs, v),
+			}
+			if n != isnil {
+				d.Category, d.Message = "maybe-nilderef", "possible "+d.Message
+			}
+			// Guard each value once.
+			if _, ok := guardedValues[v]; !ok {
+				d.SuggestedFixes = guardFixes(pass, fn, v, instr.Pos())
+				if d.SuggestedFixes != nil {
+					guardedValues[v] = struct{}{}
+				}
+			}
+			report(d)
 		}
-		reportf("nilderef", instr.Pos(), "nil dereference in "+descr)
 
 		// Only report root cause.
 
@@ -392,16 +615,22 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 				pass.ExportObjectFact(g.Object(), &alreadyReportedGlobal{})
 				return
 			}
+			// Global variables in other packages written only in
+			// the initialization have the same root cause.
+			if g, ok := u.X.(*ssa.Global); ok && st.globals[g] == globalInitOnly {
+				st.reportedGlobals[g] = struct{}{}
+				return
+			}
 		}
 
 		vrs := v.Referrers()
 		for vrs != nil {
 			nvrs := make([]ssa.Instruction, 0, 16)
 			for _, vr := range *vrs {
-				if _, ok := alreadyReported[vr]; ok {
+				if _, ok := st.alreadyReported[vr]; ok {
 					continue
 				}
-				alreadyReported[vr] = struct{}{}
+				st.alreadyReported[vr] = struct{}{}
 				if vrn, ok := vr.(ssa.Node); ok {
 					vrnrs := vrn.Referrers()
 					if vrnrs == nil {
@@ -417,6 +646,41 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 		}
 	}
 
+	// checkReturnContract reports the results of fn
+	// violating its contract.
+	fci, _ := contractOf(pass, fn.Object())
+	checkReturnContract := func(stack []nilnessOfValue, ret *ssa.Return) {
+		if len(fci.results) != len(ret.Results) {
+			return
+		}
+		name := func(i int) string {
+			if len(ret.Results) == 1 {
+				return "return value"
+			}
+			return fmt.Sprintf("return value %d", i)
+		}
+		rns := nilnessesOf(stack, ret.Results)
+		for i, a := range fci.results {
+			if a == annotatedNonnil && isNillable(ret.Results[i].Type()) && rns[i] != isnonnil {
+				reportf("contract", ret.Pos(), "%s %s violates //knil:nonnil", describe(rns[i]), name(i))
+			}
+		}
+		if !fci.nonnilIfNoError || rns[len(rns)-1] != isnil {
+			return
+		}
+		for i, n := range rns[:len(rns)-1] {
+			if isNillable(ret.Results[i].Type()) && n != isnonnil {
+				reportf("contract", ret.Pos(), "%s %s with nil error violates //knil:returns-nonnil-if",
+					describe(n), name(i))
+			}
+		}
+	}
+
+	// notNil reports an error if v can be nil.
+	notNil := func(stack []nilnessOfValue, instr ssa.Instruction, v ssa.Value, descr string) {
+		notNilf(stack, instr, v, "nil dereference in %s", descr)
+	}
+
 	visit = func(b *ssa.BasicBlock, stack []nilnessOfValue) {
 		if seen[b.Index] {
 			return
@@ -424,7 +688,14 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 		seen[b.Index] = true
 
 		// Report nil dereferences.
-		for _, instr := range b.Instrs {
+		for i, instr := range b.Instrs {
+			// Arguments of guard calls are non-nil after the calls.
+			if i > 0 {
+				stack = guarded(stack, b.Instrs[i-1])
+			}
+			if st.why.matches(instr) {
+				st.why.answer(pass, fn, stack, origs, instr)
+			}
 			// Check if the operand is already reported
 			// Global and skip if it is.
 			var rands [10]*ssa.Value
@@ -439,11 +710,14 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 						if pass.ImportObjectFact(g.Object(), f) {
 							continue
 						}
+						if _, ok := st.reportedGlobals[g]; ok {
+							continue
+						}
 					}
 				}
 			}
 
-			if _, ok := alreadyReported[instr]; ok {
+			if _, ok := st.alreadyReported[instr]; ok {
 				continue
 			}
 			switch instr := instr.(type) {
@@ -451,6 +725,21 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 				notNil(stack, instr, instr.Common().Value,
 					instr.Common().Description())
 
+				// Report arguments violating the contract of the callee.
+				if ci, ok := calleeContract(pass, instr.Common()); ok {
+					for i, a := range ci.params {
+						if a != annotatedNonnil {
+							continue
+						}
+						s := instr.Common().StaticCallee()
+						params := paramVars(s.Signature)
+						if n := nilnessOf(stack, instr.Common().Args[i]); n != isnonnil {
+							reportf("contract", instr.Pos(), "%s argument for %s of %s violates //knil:nonnil",
+								describe(n), params[len(params)-len(ci.params)+i].Name(), s.Name())
+						}
+					}
+				}
+
 				s := instr.Common().StaticCallee()
 				if s == nil {
 					continue
@@ -462,22 +751,20 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 
 				fi := functionInfo{}
 				pass.ImportObjectFact(fo, &fi)
+				merged := applyResultContract(pass, instr.Common(), mergePosToNilnesses(fi.nr))
 
-				if v, ok := instr.(ssa.Value); ok && fi.nr.length() > 0 {
+				if v, ok := instr.(ssa.Value); ok && len(merged) > 0 {
 					vrs := v.Referrers()
 					if vrs == nil {
 						continue
 					}
-					c := 0
-					merged := mergePosToNilnesses(fi.nr)
 					for _, vr := range *vrs {
 						switch i := vr.(type) {
 						case *ssa.Extract:
-							stack = append(stack, nilnessOfValue{i, merged[c]})
-							c++
+							stack = append(stack, nilnessOfValue{i, merged[i.Index]})
 						// 1 value is returned.
 						case ssa.Value:
-							if fi.nr.length() != 1 {
+							if len(merged) != 1 {
 								panic("inconsistent return values count")
 							}
 							stack = append(stack, nilnessOfValue{v, merged[0]})
@@ -486,20 +773,49 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 					}
 				}
 			case *ssa.FieldAddr:
+				// Report promoted field selections through embedded
+				// pointers with the embedded field, which may be nil.
+				if ef := embeddedField(instr.X); ef != nil {
+					sel := fieldOf(instr).Name()
+					if se := selectorAt(pass.Files, instr.Pos()); se != nil {
+						sel = types.ExprString(se)
+					}
+					notNilf(stack, instr, instr.X, "nil dereference of embedded field %s in selection %s",
+						types.TypeString(ef.Type(), types.RelativeTo(pass.Pkg)), sel)
+					continue
+				}
 				notNil(stack, instr, instr.X, "field selection")
 
-			// Currently we do not support check for index operations
+			// We do not report index operations as nil dereferences
 			// because range for slice is not Range in SSA. Range in
 			// SSA is only for map and string, and we can't distinguish
 			// range based addressing, which is safe, and naive
 			// addressing for nil, which cause an error. Also the error
 			// is index out of range, not nil pointer dereference,
-			//  even if the slice operand is nil.
+			// even if the slice operand is nil.
+			// Dereferences of nil elements loaded from fresh slices
+			// and arrays are still reported by the other cases.
 			//
-			// case *ssa.IndexAddr:
-			// 	notNil(stack, instr, instr.X, "index operation")
+			// Instead, indexing provably nil slices is reported as
+			// index out of range unless the index is checked against
+			// the length of the slice as in range loops.
+			case *ssa.IndexAddr:
+				if _, ok := instr.X.Type().Underlying().(*types.Slice); !ok {
+					continue
+				}
+				if nilnessOf(stack, instr.X) != isnil || isBoundsChecked(instr) {
+					continue
+				}
+				reportf("nilindex", instr.Pos(), "index out of range on nil slice")
 
 			case *ssa.MapUpdate:
+				if f := loadedField(instr.Map); f != nil {
+					if _, ok := st.uninitializedMaps[f]; ok {
+						notNilf(stack, instr, instr.Map, "nil dereference in map update of never initialized field %s",
+							fieldName(pass.Pkg, instr.Map))
+						continue
+					}
+				}
 				notNil(stack, instr, instr.Map, "map update")
 			case *ssa.Slice:
 				// A nilcheck occurs in ptr[:] iff ptr is a pointer to an array.
@@ -508,6 +824,15 @@ func checkFunc(pass *analysis.Pass, fn *ssa.Function, onlyCheck bool, alreadyRep
 				}
 			case *ssa.Store:
 				notNil(stack, instr, instr.Addr, "store")
+				if fa, ok := instr.Addr.(*ssa.FieldAddr); ok {
+					ci, _ := contractOf(pass, fieldOf(fa))
+					if n := nilnessOf(stack, instr.Val); ci.field == annotatedNonnil && n != isnonnil {
+						reportf("contract", instr.Pos(), "%s value stored to field %s violates //knil:nonnil",
+							describe(n), fieldOf(fa).Name())
+					}
+				}
+			case *ssa.Return:
+				checkReturnContract(stack, instr)
 			case *ssa.TypeAssert:
 				// Only the 1-result type assertion panics.
 				//
@@ -5,7 +5,12 @@
 package knil_test
 
 import (
+	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Matts966/knil/analyzer/knil"
@@ -21,3 +26,214 @@ func Test(t *testing.T) {
 	testdata := analysistest.TestData()
 	analysistest.Run(t, testdata, knil.Analyzer, "nil")
 }
+
+func TestGlobals(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, knil.Analyzer, "globals")
+}
+
+func TestConcurrent(t *testing.T) {
+	knil.SetFlag(t, "concurrent", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, knil.Analyzer, "concurrent")
+}
+
+func TestGlobalFacts(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, knil.Analyzer, "globalfacts/config", "globalfacts/use")
+}
+
+func TestContracts(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, knil.Analyzer, "contracts/api", "contracts/client")
+}
+
+func TestExternalContracts(t *testing.T) {
+	testdata := analysistest.TestData()
+	knil.SetFlag(t, "contracts", filepath.Join(testdata, "src", "external", "contracts.json"))
+	analysistest.Run(t, testdata, knil.Analyzer, "external/app")
+}
+
+// errorRecorder records the errors of the analysis in
+// analysistest.Run, ignoring the unexpected diagnostics.
+type errorRecorder struct{ errs []string }
+
+func (r *errorRecorder) Errorf(format string, args ...interface{}) {
+	if msg := fmt.Sprintf(format, args...); strings.HasPrefix(msg, "error analyzing") {
+		r.errs = append(r.errs, msg)
+	}
+}
+
+func TestExternalContractErrors(t *testing.T) {
+	testdata := analysistest.TestData()
+	for _, tt := range []struct {
+		contracts, want string
+	}{
+		{`{"external/sdk.Neww": ["nonnil endpoint"]}`, "external/sdk.Neww: no function, method or struct field"},
+		{`{"(*external/sdk.Client).Close": ["nonnil cc"]}`, "(*external/sdk.Client).Close: unknown name cc in //knil:nonnil cc"},
+		{`{"external/sdk.New": ["returns-nonnil-if e==nil"]}`, "external/sdk.New: unsupported condition e==nil in //knil:returns-nonnil-if e==nil"},
+		{`{"external/sdk.Client.Endpoint": ["nonnil"], "other/sdk.Neww": ["nonnil"]}`, ""},
+	} {
+		f, err := ioutil.TempFile("", "contracts*.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Remove(f.Name()) })
+		if _, err := f.WriteString(tt.contracts); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		knil.SetFlag(t, "contracts", f.Name())
+		r := &errorRecorder{}
+		analysistest.Run(r, testdata, knil.Analyzer, "external/sdk")
+		if tt.want == "" {
+			if len(r.errs) != 0 {
+				t.Errorf("%s: got errors %q, want none", tt.contracts, r.errs)
+			}
+			continue
+		}
+		if want := f.Name() + ": " + tt.want; len(r.errs) != 1 || !strings.HasSuffix(r.errs[0], want) {
+			t.Errorf("%s: got errors %q, want one ending with %q", tt.contracts, r.errs, want)
+		}
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	knil.SetFlag(t, "report-unused-ignores", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, knil.Analyzer, "suppress")
+}
+
+func TestConfig(t *testing.T) {
+	testdata := analysistest.TestData()
+	knil.SetFlag(t, "config", filepath.Join(testdata, "src", "configured", "knil.yaml"))
+	analysistest.Run(t, testdata, knil.Analyzer, "configured")
+}
+
+func TestConfigOverride(t *testing.T) {
+	testdata := analysistest.TestData()
+	for _, f := range []struct{ name, value string }{
+		{"config", filepath.Join(testdata, "src", "overridden", "knil.json")},
+		{"concurrent", "false"},
+		{"tests", "false"},
+	} {
+		knil.SetFlag(t, f.name, f.value)
+	}
+	analysistest.Run(t, testdata, knil.Analyzer, "overridden")
+}
+
+func TestTests(t *testing.T) {
+	knil.SetFlag(t, "tests", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, knil.Analyzer, "tests")
+}
+
+func TestGuards(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, knil.Analyzer, "guards")
+}
+
+func TestExportedParams(t *testing.T) {
+	testdata := analysistest.TestData()
+	for _, policy := range []string{"library", "application", "trusting"} {
+		t.Run(policy, func(t *testing.T) {
+			knil.SetFlag(t, "exported-params", policy)
+			analysistest.Run(t, testdata, knil.Analyzer, "policy/"+policy)
+		})
+	}
+}
+
+func TestMinSeverity(t *testing.T) {
+	knil.SetFlag(t, "min-severity", "error")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, knil.Analyzer, "severity")
+}
+
+func TestRelated(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, knil.Analyzer, "related")
+	want := map[int][]string{
+		6:  {"nil passed as p here"},
+		20: {"nil returned here by get"},
+		25: {"nil by this comparison"},
+	}
+	if n := numDiagnostics(results); n != len(want) {
+		t.Fatalf("got %d diagnostics, want %d", n, len(want))
+	}
+	for _, r := range results {
+		for _, d := range r.Diagnostics {
+			line := r.Pass.Fset.Position(d.Pos).Line
+			var got []string
+			for _, rel := range d.Related {
+				got = append(got, rel.Message)
+			}
+			if len(got) != len(want[line]) {
+				t.Errorf("line %d: got related %q, want %q", line, got, want[line])
+				continue
+			}
+			for i := range got {
+				if got[i] != want[line][i] {
+					t.Errorf("line %d: got related %q, want %q", line, got, want[line])
+				}
+			}
+		}
+	}
+}
+
+func TestRanges(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, knil.Analyzer, "related")
+	if n := numDiagnostics(results); n != 3 {
+		t.Fatalf("got %d diagnostics, want 3", n)
+	}
+	for _, r := range results {
+		for _, d := range r.Diagnostics {
+			posn, end := r.Pass.Fset.Position(d.Pos), r.Pass.Fset.Position(d.End)
+			content, err := ioutil.ReadFile(posn.Filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !d.End.IsValid() || end.Offset > len(content) || posn.Offset > end.Offset {
+				t.Errorf("%v: invalid range to %v", posn, end)
+				continue
+			}
+			// The ranges start at the selected fields.
+			if got := string(content[posn.Offset:end.Offset]); got != "f" {
+				t.Errorf("%v: got range %q, want %q", posn, got, "f")
+			}
+		}
+	}
+}
+
+func TestWhy(t *testing.T) {
+	testdata := analysistest.TestData()
+	// The identifier p dereferenced in the selection p.f.
+	pos := filepath.Join(testdata, "src", "why", "why.go") + ":7:9"
+	knil.SetFlag(t, "why", pos)
+	results := analysistest.Run(t, testdata, knil.Analyzer, "why")
+	if n := numDiagnostics(results); n != 1 {
+		t.Fatalf("got %d diagnostics, want 1", n)
+	}
+	for _, r := range results {
+		for _, d := range r.Diagnostics {
+			if len(d.Related) != 1 || d.Related[0].Message != "nil by this comparison" {
+				t.Errorf("got related %v, want the comparison", d.Related)
+			}
+		}
+	}
+}
+
+// numDiagnostics returns the number of
+// the diagnostics in the results.
+func numDiagnostics(results []*analysistest.Result) int {
+	n := 0
+	for _, r := range results {
+		n += len(r.Diagnostics)
+	}
+	return n
+}
+
+func TestCondFixes(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, knil.Analyzer, "fixcond")
+}
@@ -133,6 +133,11 @@ func nilnessOf(stack []nilnessOfValue, v ssa.Value) nilness {
 			return isnil
 		}
 		return isnonnil
+	case *ssa.UnOp:
+		// Never assigned fields of fresh allocations are nil.
+		if isNillable(v.Type()) && isZeroLoad(v) {
+			return isnil
+		}
 	}
 
 	// Search dominating control-flow facts.
@@ -141,6 +146,14 @@ func nilnessOf(stack []nilnessOfValue, v ssa.Value) nilness {
 			return f.nilness
 		}
 	}
+
+	// Just stored fields of fresh allocations hold the stored
+	// values, unless the loaded values are checked themselves.
+	if u, ok := v.(*ssa.UnOp); ok {
+		if stored := storedValue(u); stored != nil {
+			return nilnessOf(stack, stored)
+		}
+	}
 	return unknown
 }
 
@@ -0,0 +1,51 @@
+package knil
+
+// This file contains processes for the ranges of the diagnostics,
+// which start at the positions of the instructions and end at the
+// ends of the expressions there, such as f of the field selection
+// p.f, or == nil of the comparison p == nil.
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// withRanges returns a copy of pass whose Report sets the end of
+// the diagnostics without one to the end of the expression at the
+// position. The positions are kept for the -why queries and the
+// consumers of them.
+func withRanges(pass *analysis.Pass) *analysis.Pass {
+	p := *pass
+	p.Report = func(d analysis.Diagnostic) {
+		if !d.End.IsValid() {
+			if n := exprAt(pass, d.Pos); n != nil {
+				d.End = n.End()
+			}
+		}
+		pass.Report(d)
+	}
+	return &p
+}
+
+// exprAt returns the innermost expression at pos, or the node
+// starting at pos if there is no expression, or nil if there is none.
+func exprAt(pass *analysis.Pass, pos token.Pos) ast.Node {
+	f := fileOf(pass, pos)
+	if f == nil {
+		return nil
+	}
+	path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+	if len(path) == 0 {
+		return nil
+	}
+	if n, ok := path[0].(ast.Expr); ok {
+		return n
+	}
+	if path[0].Pos() == pos {
+		return path[0]
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package knil
+
+// This file contains processes for suppressing diagnostics
+// with comment directives, such as
+//
+//	x.f() //knil:ignore nilderef x is set by the framework
+//	x.f() //nolint:knil
+//
+// on the line of the diagnostic, or in the doc comment of the
+// enclosing function to suppress all the diagnostics in it.
+// The category is optional and all the categories are
+// suppressed without it.
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// reportUnusedIgnores is whether suppression directives
+// which suppress no diagnostics are reported.
+var reportUnusedIgnores bool
+
+// A suppression is a directive suppressing diagnostics.
+type suppression struct {
+	comment *ast.Comment
+	// category is the suppressed category, or
+	// empty if all the categories are suppressed.
+	category string
+	// start and end are the range of the enclosing
+	// function if the directive is in its doc comment.
+	start, end token.Pos
+	used       bool
+}
+
+// suppressions holds the suppression directives of a package.
+type suppressions struct {
+	// lines holds the directives suppressing the
+	// diagnostics on the lines.
+	lines map[token.Position][]*suppression
+	// funcs holds the directives suppressing the
+	// diagnostics in the functions.
+	funcs []*suppression
+}
+
+// nolintRegexp matches the nolint directives of golangci-lint
+// which apply to knil.
+var nolintRegexp = regexp.MustCompile(`^//\s*nolint(:[\w,-]*\bknil\b[\w,-]*)?(\s|$)`)
+
+// parseSuppression returns the suppression of the comment,
+// or nil if the comment is not a suppression directive.
+func parseSuppression(c *ast.Comment) *suppression {
+	if nolintRegexp.MatchString(c.Text) {
+		return &suppression{comment: c}
+	}
+	if !strings.HasPrefix(c.Text, directivePrefix) {
+		return nil
+	}
+	fs := strings.Fields(strings.TrimPrefix(c.Text, directivePrefix))
+	if len(fs) == 0 || fs[0] != "ignore" {
+		return nil
+	}
+	s := &suppression{comment: c}
+	if len(fs) > 1 && isCategory(fs[1]) {
+		s.category = fs[1]
+	}
+	return s
+}
+
+// parseSuppressions returns the suppression directives
+// in the files of the package.
+func parseSuppressions(pass *analysis.Pass) *suppressions {
+	ss := &suppressions{lines: make(map[token.Position][]*suppression)}
+	for _, f := range pass.Files {
+		inDoc := make(map[*ast.Comment]struct{})
+		for _, d := range f.Decls {
+			fd, ok := d.(*ast.FuncDecl)
+			if !ok || fd.Doc == nil {
+				continue
+			}
+			for _, c := range fd.Doc.List {
+				if s := parseSuppression(c); s != nil {
+					// The directives of the doc comment,
+					// such as contracts, are also suppressed.
+					s.start, s.end = fd.Doc.Pos(), fd.End()
+					ss.funcs = append(ss.funcs, s)
+					inDoc[c] = struct{}{}
+				}
+			}
+		}
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				if _, ok := inDoc[c]; ok {
+					continue
+				}
+				if s := parseSuppression(c); s != nil {
+					l := lineOf(pass.Fset, c.Pos())
+					ss.lines[l] = append(ss.lines[l], s)
+				}
+			}
+		}
+	}
+	return ss
+}
+
+// lineOf returns the position of the line of pos.
+func lineOf(fset *token.FileSet, pos token.Pos) token.Position {
+	p := fset.Position(pos)
+	return token.Position{Filename: p.Filename, Line: p.Line}
+}
+
+// suppressed reports whether the diagnostic of the category
+// at pos is suppressed, and marks the directives used.
+func (ss *suppressions) suppressed(fset *token.FileSet, category string, pos token.Pos) bool {
+	found := false
+	match := func(s *suppression) {
+		if s.category == "" || s.category == category {
+			s.used = true
+			found = true
+		}
+	}
+	for _, s := range ss.lines[lineOf(fset, pos)] {
+		match(s)
+	}
+	for _, s := range ss.funcs {
+		if s.start <= pos && pos < s.end {
+			match(s)
+		}
+	}
+	return found
+}
+
+// filterReports returns a copy of pass whose Report
+// drops the suppressed diagnostics.
+func (ss *suppressions) filterReports(pass *analysis.Pass) *analysis.Pass {
+	p := *pass
+	p.Report = func(d analysis.Diagnostic) {
+		if ss.suppressed(pass.Fset, d.Category, d.Pos) {
+			return
+		}
+		pass.Report(d)
+	}
+	return &p
+}
+
+// reportUnused reports the directives which
+// suppress no diagnostics. The ones in ignored
+// files are dropped by the Report of pass.
+func (ss *suppressions) reportUnused(pass *analysis.Pass) {
+	unused := []*suppression{}
+	for _, ls := range ss.lines {
+		unused = append(unused, ls...)
+	}
+	unused = append(unused, ss.funcs...)
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].comment.Pos() < unused[j].comment.Pos()
+	})
+	for _, s := range unused {
+		if s.used {
+			continue
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos:      s.comment.Pos(),
+			End:      s.comment.End(),
+			Category: "ignore",
+			Message:  "unused suppression " + s.comment.Text,
+		})
+	}
+}
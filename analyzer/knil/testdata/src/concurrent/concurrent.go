@@ -0,0 +1,39 @@
+package concurrent // want package:"done"
+
+type conf struct{ port int }
+
+func newConf() *conf { // want newConf:"arguments: map\\[\\], return value: map\\[[0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	return &conf{}
+}
+
+var (
+	current = &conf{}   // want current:"already reported global"
+	created = newConf() // want created:"already reported global"
+	byInit  *conf       // want byInit:"already reported global"
+	later   *conf       // want later:"already reported global"
+	mayNil  *conf       // want mayNil:"already reported global"
+	escaped *conf       // want escaped:"already reported global"
+)
+
+func init() {
+	byInit = &conf{port: 1}
+	mayNil = &conf{}
+	escaped = &conf{}
+}
+
+func set(c *conf) { // want set:"arguments: map\\[[0-9]+:\\[unknown\\]\\], return value: map\\[\\], potential free variable: map\\[\\]"
+	later = &conf{}
+	mayNil = c
+	p := &escaped
+	*p = nil
+}
+
+func use() {
+	print(current.port) // want "nil dereference in field selection"
+	print(created.port) // want "nil dereference in field selection"
+	print(byInit.port)  // want "nil dereference in field selection"
+	print(later.port)   // want "nil dereference in field selection"
+	print(mayNil.port)  // want "nil dereference in field selection"
+	print(escaped.port) // want "nil dereference in field selection"
+	set(current)
+}
@@ -0,0 +1,68 @@
+package configured // want package:"done"
+
+import (
+	"log"
+	"os"
+	"reflect"
+)
+
+type T struct {
+	f *int
+}
+
+// Exported may be called with nil in other packages.
+func Exported(t *T) { // want Exported:"arguments: map\\[[0-9]+:\\[non-nil\\]\\], return value: map\\[\\], potential free variable: map\\[\\]"
+	print(t.f) // want "nil dereference in field selection"
+}
+
+func unexported(t *T) { // want unexported:"arguments: map\\[[0-9]+:\\[non-nil\\]\\], return value: map\\[\\], potential free variable: map\\[\\]"
+	print(t.f)
+}
+
+func call() {
+	Exported(&T{})
+	unexported(&T{})
+}
+
+// mustNotNil is a guard only by the configuration
+// because it checks t with reflection.
+func mustNotNil(t *T) { // want mustNotNil:"arguments: map\\[[0-9]+:\\[unknown\\]\\], return value: map\\[\\], potential free variable: map\\[\\]"
+	if reflect.ValueOf(t).IsNil() {
+		panic("nil")
+	}
+}
+
+// mustBeSet is a configured guard of an interface, which
+// is non-nil even if the converted pointer is nil.
+func mustBeSet(v interface{}) { // want mustBeSet:"guard: \\[nonnil\\]" mustBeSet:"arguments: .*"
+	if v == nil {
+		panic("nil")
+	}
+}
+
+func die(msg string) { // want die:"arguments: map\\[[0-9]+:\\[non-nil\\]\\], return value: map\\[\\], potential free variable: map\\[\\]"
+	os.Exit(1)
+}
+
+func guarded(t, u, v *T) { // want guarded:"guard: \\[nonnil nonnil nonnil\\]"
+	mustNotNil(t)
+	print(t.f)
+	if u == nil {
+		die("nil")
+	}
+	print(u.f)
+	if v == nil {
+		log.Fatal("nil")
+	}
+	print(v.f)
+	ch := make(chan int)
+	if ch == nil {
+		print(0)
+	}
+}
+
+func typedNil() {
+	var t *T
+	mustBeSet(t)
+	print(t.f) // want "^nil dereference in field selection$"
+}
@@ -0,0 +1,5 @@
+package configured
+
+func generated(t *T) {
+	print(t.f)
+}
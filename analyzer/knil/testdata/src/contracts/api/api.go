@@ -0,0 +1,59 @@
+package api // want package:"done"
+
+import "errors"
+
+type Conn struct {
+	//knil:nonnil
+	Addr *string // want Addr:"contract: nonnil"
+	Buf  *[]byte //knil:nilable // want Buf:"contract: nilable"
+	n    int     //knil:nonnil // want "n is never nil"
+}
+
+// Dial connects to addr.
+//
+//knil:nonnil addr
+//knil:returns-nonnil-if err==nil
+func Dial(addr *string) (*Conn, error) { // want Dial:"contract: params \\[nonnil\\], results \\[- -\\], nonnil if err == nil" Dial:"arguments: map\\[\\], return value: map\\[[0-9]+:\\[nil non-nil\\] [0-9]+:\\[nil nil\\] [0-9]+:\\[non-nil nil\\]\\], potential free variable: map\\[\\]"
+	if *addr == "" {
+		return nil, errors.New("empty address")
+	}
+	if len(*addr) > 10 {
+		return nil, nil // want "nil return value 0 with nil error violates //knil:returns-nonnil-if"
+	}
+	return &Conn{Addr: addr}, nil
+}
+
+// Default returns the default connection.
+//
+//knil:nonnil return
+func Default() *Conn { // want Default:"contract: params \\[\\], results \\[nonnil\\]" Default:"arguments: map\\[\\], return value: map\\[[0-9]+:\\[nil\\]\\], potential free variable: map\\[\\]"
+	return nil // want "nil return value violates //knil:nonnil"
+}
+
+// Lookup finds the connection.
+//
+//knil:nilable return
+//knil:nonnil name // want "name is never nil"
+func Lookup(name string) *Conn { // want Lookup:"contract: params \\[-\\], results \\[nilable\\]" Lookup:"arguments: map\\[\\], return value: map\\[[0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	return &Conn{}
+}
+
+// Close closes c.
+//
+//knil:nonnil c unknown // want "unknown name unknown in //knil:nonnil c unknown"
+func (c *Conn) Close() { // want Close:"contract: params \\[nonnil\\], results \\[\\]"
+	print(*c.Addr)
+	c.Addr = nil // want "nil value stored to field Addr violates //knil:nonnil"
+}
+
+// addrOf returns the address of c, which may be nil
+// although the callers in the package pass non-nil.
+//
+//knil:nilable c
+func addrOf(c *Conn) *string { // want addrOf:"contract: params \\[nilable\\], results \\[-\\]" addrOf:"arguments: map\\[[0-9]+:\\[non-nil\\]\\], return value: map\\[[0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	return c.Addr // want "^possible nil dereference in field selection$"
+}
+
+func defaultAddr() *string { // want defaultAddr:"arguments: .*"
+	return addrOf(&Conn{})
+}
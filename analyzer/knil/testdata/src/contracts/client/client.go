@@ -0,0 +1,18 @@
+package client // want package:"done"
+
+import "contracts/api"
+
+func use(addr *string) {
+	c, err := api.Dial(addr) // want "possibly nil argument for addr of Dial violates //knil:nonnil"
+	if err != nil {
+		return
+	}
+	print(c.Buf)
+	print(*c.Buf) // want "nil dereference in load"
+	c.Close()
+	api.Lookup("x").Close() // want "possibly nil argument for c of Close violates //knil:nonnil"
+	d := api.Default()
+	d.Close()
+	var s *string
+	api.Dial(s) // want "nil argument for addr of Dial violates //knil:nonnil"
+}
@@ -0,0 +1,16 @@
+package app // want package:"done"
+
+import "external/sdk"
+
+func connect(endpoint *string) {
+	sdk.New(nil)                // want "nil argument for endpoint of New violates //knil:nonnil"
+	c, err := sdk.New(endpoint) // want "possibly nil argument for endpoint of New violates //knil:nonnil"
+	if err != nil {
+		return
+	}
+	print(*c.Endpoint)
+	print(*c.Timeout) // want "nil dereference in load"
+	c.Close()
+	var d *sdk.Client
+	d.Close() // want "nil argument for c of Close violates //knil:nonnil"
+}
@@ -0,0 +1,19 @@
+package sdk
+
+import "errors"
+
+type Client struct {
+	Endpoint *string
+	Timeout  *int
+}
+
+func New(endpoint *string) (*Client, error) {
+	if len(*endpoint) == 0 {
+		return nil, errors.New("empty endpoint")
+	}
+	return &Client{Endpoint: endpoint}, nil
+}
+
+func (c *Client) Close() {
+	print(*c.Endpoint)
+}
@@ -0,0 +1,78 @@
+package fixcond // want package:"done"
+
+type T struct{ f int }
+
+func tautological() {
+	t := &T{}
+	if t != nil { // want "tautological condition: non-nil != nil"
+		print(t.f)
+	}
+}
+
+func tautologicalElse() {
+	t := &T{}
+	if t != nil { // want "tautological condition: non-nil != nil"
+		print(t.f)
+	} else {
+		print(0)
+	}
+}
+
+func impossible() {
+	t := &T{}
+	if t == nil { // want "impossible condition: non-nil == nil"
+		print(0)
+	}
+	print(t.f)
+}
+
+func impossibleElse() {
+	t := &T{}
+	if t == nil { // want "impossible condition: non-nil == nil"
+		print(0)
+	} else {
+		print(t.f)
+	}
+}
+
+func impossibleElseIf(b bool) {
+	t := &T{}
+	if b {
+		print(0)
+	} else if t == nil { // want "impossible condition: non-nil == nil"
+		print(1)
+	}
+	print(t.f)
+}
+
+// The last use of ch is in the condition.
+func lastUse() {
+	ch := make(chan int)
+	if ch == nil { // want "impossible condition: non-nil == nil"
+		print(0)
+	}
+}
+
+// Compound conditions are not rewritten.
+func compound(b bool) {
+	t := &T{}
+	if b && t == nil { // want "impossible condition: non-nil == nil"
+		print(0)
+	}
+	print(t.f)
+}
+
+func newT() *T { // want newT:"arguments: map\\[[0-9]+:\\[\\]\\], return value: map\\[[0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	return &T{}
+}
+
+// The comparisons of calls are not rewritten
+// because the calls would be dropped.
+func calls() {
+	if newT() == nil { // want "impossible condition: non-nil == nil"
+		print(0)
+	}
+	if newT() != nil { // want "tautological condition: non-nil != nil"
+		print(1)
+	}
+}
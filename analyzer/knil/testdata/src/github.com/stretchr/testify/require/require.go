@@ -0,0 +1,12 @@
+package require
+
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	FailNow()
+}
+
+func NotNil(t TestingT, object interface{}, msgAndArgs ...interface{}) {
+	if object == nil {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,24 @@
+package config // want package:"done"
+
+type Config struct{ Port int }
+
+func load() *Config { // want load:"arguments: map\\[\\], return value: map\\[[0-9]+:\\[nil\\] [0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	if defaultConfig.Port == 0 {
+		return nil
+	}
+	return &Config{}
+}
+
+var defaultConfig = Config{Port: 80}
+
+var (
+	// Importing packages may set Default to nil.
+	Default = &Config{} // want Default:"global: written only in init"
+	Loaded  = load()    // want Loaded:"global: written only in init"
+	Unset   *Config     // want Unset:"global: written only in init"
+	Current *Config     // want Current:"global: may be nil"
+)
+
+func Set(c *Config) {
+	Current = c
+}
@@ -0,0 +1,13 @@
+package use // want package:"done"
+
+import "globalfacts/config"
+
+func use() {
+	print(config.Default.Port) // want "^possible nil dereference in field selection$"
+	print(config.Default.Port)
+	print(config.Loaded.Port) // want "nil dereference in field selection"
+	print(config.Loaded.Port)
+	print(config.Unset.Port)   // want "nil dereference in field selection"
+	print(config.Current.Port) // want "nil dereference in field selection"
+	print(config.Current.Port) // want "nil dereference in field selection"
+}
@@ -0,0 +1,43 @@
+package globals // want package:"done"
+
+type conf struct{ port int }
+
+func newConf() *conf { // want newConf:"arguments: map\\[\\], return value: map\\[[0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	return &conf{}
+}
+
+var (
+	current = &conf{}
+	created = newConf()
+	byInit  *conf
+	later   *conf // want later:"already reported global"
+	mayNil  *conf // want mayNil:"already reported global"
+	escaped *conf // want escaped:"already reported global"
+
+	// Importing packages may set Shared to nil.
+	Shared = &conf{} // want Shared:"global: written only in init" Shared:"already reported global"
+)
+
+func init() {
+	byInit = &conf{port: 1}
+	mayNil = &conf{}
+	escaped = &conf{}
+}
+
+func set(c *conf) { // want set:"arguments: map\\[[0-9]+:\\[non-nil\\]\\], return value: map\\[\\], potential free variable: map\\[\\]"
+	later = &conf{}
+	mayNil = c
+	p := &escaped
+	*p = nil
+}
+
+func use() {
+	print(current.port)
+	print(created.port)
+	print(byInit.port)
+	print(later.port)   // want "nil dereference in field selection"
+	print(mayNil.port)  // want "nil dereference in field selection"
+	print(escaped.port) // want "nil dereference in field selection"
+	print(Shared.port)  // want "^possible nil dereference in field selection$"
+	set(current)
+}
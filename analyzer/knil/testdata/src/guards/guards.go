@@ -0,0 +1,58 @@
+package guards // want package:"done"
+
+import "log"
+
+type T struct {
+	f *int
+}
+
+func must(v interface{}) { // want must:"guard: \\[nonnil\\]" must:"arguments: .*"
+	if v == nil { // want "impossible condition: non-nil == nil"
+		panic("nil")
+	}
+}
+
+func check(ok bool) { // want check:"guard: \\[true\\]" check:"arguments: .*"
+	if !ok {
+		panic("check failed")
+	}
+}
+
+// assertNotNil is not a guard because must
+// does not catch nil pointers in interfaces.
+func assertNotNil(t *T) { // want assertNotNil:"arguments: .*"
+	must(t)
+}
+
+func fatalIfNil(t *T, msg string) { // want fatalIfNil:"guard: \\[nonnil -\\]" fatalIfNil:"arguments: .*"
+	if t == nil {
+		log.Fatal(msg)
+	}
+}
+
+func returnIfNil(t *T) { // want returnIfNil:"arguments: .*"
+	if t == nil {
+		return
+	}
+	print("checked")
+}
+
+// use is also a guard because it calls the guards.
+func use(a, b, c, d, e *T) { // want use:"guard: \\[- nonnil - nonnil -\\]"
+	must(a)
+	print(a.f) // want "^possible nil dereference in field selection$"
+	check(b != nil)
+	print(b.f)
+	assertNotNil(c)
+	print(c.f) // want "^possible nil dereference in field selection$"
+	fatalIfNil(d, "nil")
+	print(d.f)
+	returnIfNil(e)
+	print(e.f) // want "^possible nil dereference in field selection$"
+}
+
+func typedNil() {
+	var a *T
+	must(a)
+	print(a.f) // want "^nil dereference in field selection$"
+}
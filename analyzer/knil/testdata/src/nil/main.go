@@ -196,3 +196,148 @@ func y() {
 	i := 3
 	x2(&i)
 }
+
+type inner struct{ x int }
+type outer struct {
+	*inner
+	y int
+}
+
+func embedded(o *outer) {
+	if o != nil {
+		o.y = 1
+		o.x = 1 // want "nil dereference of embedded field \\*inner in selection o.x"
+	}
+	v := outer{}
+	print(v.x) // want "nil dereference of embedded field \\*inner in selection v.x"
+}
+
+type config struct{ port int }
+type server struct {
+	handlers map[string]int
+	cfg      *config
+	name     string
+}
+
+func (sv *server) init() {} // want init:"arguments: map\\[[0-9]+:\\[non-nil\\] [0-9]+:\\[non-nil\\]\\], return value: map\\[\\], potential free variable: map\\[\\]"
+
+func zero() {
+	s := &server{}
+	s.handlers["x"] = 1 // want "^nil dereference in map update$"
+	print(s.cfg.port)   // want "^nil dereference in field selection$"
+
+	t := new(server)
+	t.cfg = &config{}
+	print(t.cfg.port)
+	if t.handlers != nil { // want "impossible condition: nil != nil"
+		print(0)
+	}
+
+	u := &server{handlers: map[string]int{}}
+	u.handlers["x"] = 1
+
+	var w server
+	w.init()
+	print(w.cfg.port) // want "^possible nil dereference in field selection$"
+}
+
+func stored(c *config) {
+	s := &server{}
+	s.cfg = c
+	print(s.cfg.port) // want "^possible nil dereference in field selection$"
+	s.cfg = nil
+	print(s.cfg.port) // want "^nil dereference in field selection$"
+
+	t := &server{cfg: &config{}}
+	t.init()
+	print(t.cfg.port) // want "^possible nil dereference in field selection$"
+
+	// The checks of the loaded values precede the stored values.
+	u := &server{}
+	u.cfg = c
+	x := u.cfg
+	if x != nil {
+		print(x.port)
+	}
+}
+
+type registry struct {
+	entries map[string]int
+	names   map[string]int
+}
+
+func newRegistry() *registry { // want newRegistry:"arguments: map\\[[0-9]+:\\[\\]\\], return value: map\\[[0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	return &registry{names: make(map[string]int)}
+}
+
+func useRegistry() {
+	r := newRegistry()
+	r.entries["k"] = 1 // want "^nil dereference in map update of never initialized field registry.entries$"
+	r.names["k"] = 1   // want "^possible nil dereference in map update$"
+	if r.entries == nil { // want "tautological condition: nil == nil"
+		print(0)
+	}
+}
+
+type lookup struct{ keys map[string]int }
+
+func newLookup() *lookup { // want newLookup:"arguments: map\\[[0-9]+:\\[\\]\\], return value: map\\[[0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	return &lookup{}
+}
+
+func useLookup() {
+	i := newLookup()
+	i.keys["k"] = 1 // want "^possible nil dereference in map update$"
+
+	j := &lookup{}
+	j.keys = make(map[string]int)
+	j.keys["k"] = 1
+}
+
+func elements(n, i int) {
+	xs := make([]*config, n)
+	xs[0].port = 1 // want "^nil dereference in field selection$"
+	for _, x := range xs {
+		print(x.port) // want "^nil dereference in field selection$"
+	}
+	print(len(xs))
+
+	var arr [3]*config
+	if arr[1] != nil { // want "impossible condition: nil != nil"
+		print(0)
+	}
+
+	ys := make([]*config, n)
+	ys[0] = &config{}
+	ys[0].port = 1
+	ys[1].port = 1 // want "^nil dereference in field selection$"
+	ys[i] = &config{}
+	ys[i].port = 1
+	ys[1].port = 2 // want "^possible nil dereference in field selection$"
+
+	var zs0 [2]*config
+	zs0[1] = &config{}
+	if zs0[1] == nil { // want "impossible condition: non-nil == nil"
+		print(0)
+	}
+
+	zs := make([]*config, 0, n)
+	if zs[0] != nil {
+		print(0)
+	}
+}
+
+func index(t []int) {
+	var s []int
+	s[0] = 1 // want "index out of range on nil slice"
+	for i := range s {
+		s[i] = 2
+	}
+	for i := 0; i < len(s); i++ {
+		s[i] = 3
+	}
+	if t == nil {
+		print(t[1]) // want "index out of range on nil slice"
+	}
+	print(t[0])
+}
@@ -0,0 +1,17 @@
+package overridden // want package:"done"
+
+type conf struct{ port int }
+
+var current = &conf{}
+
+func use() {
+	// The flags override the concurrent configuration.
+	print(current.port)
+}
+
+func find(ok bool) *conf { // want find:"arguments: map\\[\\], return value: map\\[[0-9]+:\\[nil\\] [0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	if !ok {
+		return nil
+	}
+	return &conf{}
+}
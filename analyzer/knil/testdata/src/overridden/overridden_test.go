@@ -0,0 +1,8 @@
+package overridden
+
+import "testing"
+
+func TestFind(t *testing.T) {
+	// The flags override the tests configuration.
+	print(find(false).port)
+}
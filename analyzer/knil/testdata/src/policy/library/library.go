@@ -0,0 +1,29 @@
+package library // want package:"done"
+
+type T struct {
+	f *int
+}
+
+// Uncalled has no callers in the package.
+func Uncalled(t *T) {
+	print(t.f) // want "^possible nil dereference in field selection$"
+}
+
+// Called is called only with non-nil in the package.
+func Called(t *T) { // want Called:"arguments: map\\[[0-9]+:\\[non-nil\\]\\], return value: map\\[\\], potential free variable: map\\[\\]"
+	print(t.f) // want "^possible nil dereference in field selection$"
+}
+
+// Nilable is annotated to accept nil for t, although
+// it is called only with non-nil in the package.
+//
+//knil:nilable t
+func Nilable(t, u *T) { // want Nilable:"contract: params \\[nilable -\\], results \\[\\]" Nilable:"arguments: map\\[[0-9]+:\\[non-nil non-nil\\]\\], return value: map\\[\\], potential free variable: map\\[\\]"
+	print(t.f) // want "^possible nil dereference in field selection$"
+	print(u.f) // want "^possible nil dereference in field selection$"
+}
+
+func call() {
+	Called(&T{})
+	Nilable(&T{}, &T{})
+}
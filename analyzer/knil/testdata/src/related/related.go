@@ -0,0 +1,27 @@
+package related // want package:"done"
+
+type T struct{ f int }
+
+func deref(p *T) int { // want deref:"arguments: .*"
+	return p.f // want "possible nil dereference in field selection"
+}
+
+func callers() {
+	deref(nil)
+	deref(&T{})
+}
+
+func get() *T { // want get:"arguments: .*"
+	return nil
+}
+
+func useGet() {
+	p := get()
+	print(p.f) // want "nil dereference in field selection"
+}
+
+func compared(p *T) {
+	if p == nil {
+		print(p.f) // want "nil dereference in field selection"
+	}
+}
@@ -0,0 +1,7 @@
+package severity // want package:"done"
+
+func deref(p *int) {
+	var q *int
+	print(*q) // want "^nil dereference in load"
+	print(*p) // possible nil dereference, which is a warning
+}
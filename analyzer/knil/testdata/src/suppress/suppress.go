@@ -0,0 +1,39 @@
+package suppress // want package:"done"
+
+type T struct {
+	f *int
+}
+
+func line(a, b, c, d, e, f *T) {
+	print(a.f)    //knil:ignore maybe-nilderef a is never nil
+	print(b.f)    //nolint:knil
+	print(c.f)    //nolint:errcheck,knil // c is never nil
+	print(d.f)    //nolint
+	print(e.f)    //knil:ignore cond wrong category // want "nil dereference in field selection" "unused suppression //knil:ignore cond"
+	print(f.f)    //nolint:errcheck // want "nil dereference in field selection"
+	print(a != b) //knil:ignore // want "unused suppression //knil:ignore"
+}
+
+// fun is called with t set by the framework.
+//
+//knil:ignore maybe-nilderef
+func fun(t *T) {
+	print(t.f)
+	func() {
+		print(*t.f)
+	}()
+}
+
+// unused is annotated, but nothing is reported.
+//
+//knil:ignore // want "unused suppression //knil:ignore // want"
+func unused() {
+}
+
+// lookup has a malformed contract, which is suppressed.
+//
+//knil:nonnil name
+//knil:ignore contract name is a string
+func lookup(name string) *T { // want lookup:"arguments: map\\[\\], return value: map\\[[0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	return &T{}
+}
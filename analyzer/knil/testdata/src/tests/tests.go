@@ -0,0 +1,16 @@
+package tests // want package:"done"
+
+type T struct {
+	f *int
+}
+
+func New(ok bool) *T { // want New:"arguments: map\\[.*\\], return value: map\\[[0-9]+:\\[nil\\] [0-9]+:\\[non-nil\\]\\], potential free variable: map\\[\\]"
+	if !ok {
+		return nil
+	}
+	return &T{}
+}
+
+// values holds the values whose nilness
+// only the nil checks of the tests tell.
+var values = map[string]*T{}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFatal(t *testing.T) {
+	v := values["v"]
+	if v == nil {
+		t.Fatal("nil")
+	}
+	print(v.f)
+}
+
+func TestSkip(t *testing.T) {
+	v := values["v"]
+	if v == nil {
+		t.Skip("nil")
+	}
+	print(v.f)
+}
+
+func TestTB(t *testing.T) {
+	checkTB(t)
+}
+
+func checkTB(tb testing.TB) { // want checkTB:"arguments: map\\[[0-9]+:\\[non-nil\\]\\], return value: map\\[\\], potential free variable: map\\[\\]"
+	v := values["v"]
+	if v == nil {
+		tb.FailNow()
+	}
+	print(v.f)
+}
+
+func TestRequire(t *testing.T) {
+	v := New(true)
+	require.NotNil(t, v)
+	print(v.f)
+}
+
+func TestError(t *testing.T) {
+	v := values["v"]
+	if v == nil {
+		t.Error("nil")
+	}
+	print(v.f) // want "^possible nil dereference in field selection$"
+}
@@ -0,0 +1,10 @@
+package why // want package:"done"
+
+type T struct{ f int }
+
+func compared(p *T) {
+	if p == nil {
+		print(p.f) // want "p is nil"
+	}
+	print(p.f)
+}
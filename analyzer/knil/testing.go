@@ -0,0 +1,73 @@
+package knil
+
+// This file contains models of the testing package and the
+// assertion libraries, for the analysis of the test files.
+// The functions failing tests never return, and the arguments
+// of the assertions of non-nilness are non-nil after them.
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+// analyzeTests is whether the test files are analyzed.
+var analyzeTests boolFlag
+
+var testFilesRegexp = regexp.MustCompile(`.*_test.go`)
+
+// testNoReturn holds the methods of testing.T and
+// testing.B which never return.
+var testNoReturn = func() []string {
+	var names []string
+	for _, m := range []string{"Fatal", "Fatalf", "FailNow", "Skip", "Skipf", "SkipNow"} {
+		names = append(names, "(*testing.common)."+m, "(testing.TB)."+m)
+	}
+	return names
+}()
+
+// testGuards holds the assertions of non-nilness with
+// the indices of the asserted arguments. They check the
+// values in the interfaces with reflection.
+//
+// The assert package continues the tests after the failed
+// assertions, but dereferencing the nil values only panics
+// the tests which are already failed.
+var testGuards = map[string][]int{
+	"github.com/stretchr/testify/require.NotNil":               {1},
+	"github.com/stretchr/testify/assert.NotNil":                {1},
+	"(*github.com/stretchr/testify/require.Assertions).NotNil": {1},
+	"(*github.com/stretchr/testify/assert.Assertions).NotNil":  {1},
+}
+
+func isTestFile(name string) bool {
+	return testFilesRegexp.MatchString(name)
+}
+
+// isTestMain reports whether the package is the main
+// package generated for tests, which has nothing to check.
+func isTestMain(pass *analysis.Pass) bool {
+	return pass.Pkg.Name() == "main" && strings.HasSuffix(pass.Pkg.Path(), ".test")
+}
+
+// testParamFacts returns the facts of the parameters of
+// the test functions, which are never called with nil.
+func testParamFacts(fn *ssa.Function) []nilnessOfValue {
+	if fn.Parent() != nil || fn.Signature.Recv() != nil || !isTestFile(getFileNameOfFunction(fn)) {
+		return nil
+	}
+	name := fn.Name()
+	if !strings.HasPrefix(name, "Test") && !strings.HasPrefix(name, "Benchmark") && !strings.HasPrefix(name, "Fuzz") {
+		return nil
+	}
+	var fs []nilnessOfValue
+	for _, p := range fn.Params {
+		switch p.Type().String() {
+		case "*testing.T", "*testing.B", "*testing.F", "*testing.M":
+			fs = append(fs, nilnessOfValue{p, isnonnil})
+		}
+	}
+	return fs
+}
@@ -0,0 +1,213 @@
+package knil
+
+// This file contains processes for the query of the nilness
+// inferred for the values at a position, such as
+//
+//	knil -why=p.go:20:10 ./...
+//
+// which reports the nilness of the operands of the
+// instructions at the position with the chain of the facts
+// leading to it, such as the dominating comparisons and the
+// summaries of the functions. The position is usually the
+// one of a diagnostic, or an identifier dereferenced there.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ssa"
+)
+
+// whyPosition is the position of the query
+// in the form of file.go:LINE:COL.
+var whyPosition string
+
+// A whyQuery is the query of the nilness at a position in a package.
+type whyQuery struct {
+	// pos is the queried position.
+	pos token.Pos
+	// targets holds the positions of the instructions
+	// whose operands are queried.
+	targets map[token.Pos]struct{}
+	// report reports the answers bypassing the
+	// filters and the suppressions.
+	report func(analysis.Diagnostic)
+	found  bool
+}
+
+// parsePosition parses the position in the
+// form of file.go:LINE:COL.
+func parsePosition(s string) (file string, line, col int, err error) {
+	fs := strings.Split(s, ":")
+	if len(fs) < 3 {
+		return "", 0, 0, fmt.Errorf("invalid position %s, want file.go:LINE:COL", s)
+	}
+	n := len(fs)
+	if line, err = strconv.Atoi(fs[n-2]); err != nil || line < 1 {
+		return "", 0, 0, fmt.Errorf("invalid line in position %s", s)
+	}
+	if col, err = strconv.Atoi(fs[n-1]); err != nil || col < 1 {
+		return "", 0, 0, fmt.Errorf("invalid column in position %s", s)
+	}
+	file, err = filepath.Abs(strings.Join(fs[:n-2], ":"))
+	return file, line, col, err
+}
+
+// newWhyQuery returns the query of whyPosition in the
+// package, or nil if the position is not in it.
+func newWhyQuery(pass *analysis.Pass) (*whyQuery, error) {
+	if whyPosition == "" {
+		return nil, nil
+	}
+	file, line, col, err := parsePosition(whyPosition)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range pass.Files {
+		tf := pass.Fset.File(f.Pos())
+		if tf == nil || filepath.Clean(tf.Name()) != file {
+			continue
+		}
+		if line > tf.LineCount() {
+			return nil, fmt.Errorf("line %d out of range in %s", line, file)
+		}
+		pos := tf.LineStart(line) + token.Pos(col-1)
+		if int(pos) > tf.Base()+tf.Size() {
+			return nil, fmt.Errorf("column %d out of range in %s:%d", col, file, line)
+		}
+		return &whyQuery{
+			pos:     pos,
+			targets: queryTargets(f, pos),
+			report:  pass.Report,
+		}, nil
+	}
+	return nil, nil
+}
+
+// queryTargets returns the positions of the instructions whose
+// operands are queried by pos. If pos is on an identifier, the
+// position of the enclosing selection, dereference, index or
+// call is also a target, where the instruction using the
+// identifier is.
+func queryTargets(f *ast.File, pos token.Pos) map[token.Pos]struct{} {
+	targets := map[token.Pos]struct{}{pos: {}}
+	path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+	if len(path) < 2 {
+		return targets
+	}
+	id, ok := path[0].(*ast.Ident)
+	if !ok {
+		return targets
+	}
+	var e ast.Expr = id
+	for _, n := range path[1:] {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if n.X != e {
+				return targets
+			}
+			targets[n.Sel.Pos()] = struct{}{}
+		case *ast.StarExpr:
+			if n.X != e {
+				return targets
+			}
+			targets[n.Star] = struct{}{}
+		case *ast.IndexExpr:
+			if n.X != e {
+				return targets
+			}
+			targets[n.Lbrack] = struct{}{}
+		case *ast.CallExpr:
+			if n.Fun != e {
+				return targets
+			}
+			targets[n.Lparen] = struct{}{}
+		default:
+			return targets
+		}
+		e = n.(ast.Expr)
+	}
+	return targets
+}
+
+// matches reports whether the operands of instr are queried.
+func (q *whyQuery) matches(instr ssa.Instruction) bool {
+	if q == nil {
+		return false
+	}
+	_, ok := q.targets[instr.Pos()]
+	return ok
+}
+
+// answer reports the nilness of the operands of instr
+// with the chain of the facts leading to it.
+func (q *whyQuery) answer(pass *analysis.Pass, fn *ssa.Function, stack []nilnessOfValue, origs origins, instr ssa.Instruction) {
+	var rands [10]*ssa.Value
+	for _, rand := range instr.Operands(rands[:0]) {
+		v := *rand
+		if v == nil || !isNillable(v.Type()) {
+			continue
+		}
+		switch v := v.(type) {
+		case *ssa.Const, *ssa.Function, *ssa.Builtin:
+			continue
+		case ssa.Instruction:
+			// Skip the values derived in the same expression
+			// such as the address of a selected field.
+			if q.matches(v) {
+				continue
+			}
+		}
+
+		var related []analysis.RelatedInformation
+		for _, f := range stack {
+			if f.value != v || f.nilness == unknown {
+				continue
+			}
+			if o, ok := origs[f]; ok {
+				related = append(related, analysis.RelatedInformation{Pos: o.pos, Message: o.message})
+			}
+		}
+		related = append(related, summaryTrace(pass, fn, v)...)
+		q.found = true
+		q.report(analysis.Diagnostic{
+			Pos:      instr.Pos(),
+			Category: "why",
+			Message:  fmt.Sprintf("%s is %s", valueName(v), nilnessOf(stack, v)),
+			Related:  related,
+		})
+	}
+}
+
+// reportNotFound reports that no value is found at the
+// queried position.
+func (q *whyQuery) reportNotFound() {
+	if q == nil || q.found {
+		return
+	}
+	q.report(analysis.Diagnostic{
+		Pos:      q.pos,
+		Category: "why",
+		Message:  "no nillable value found at the position",
+	})
+}
+
+// valueName returns the name of v in the source if any,
+// or the description of v in SSA.
+func valueName(v ssa.Value) string {
+	switch v := v.(type) {
+	case *ssa.Parameter, *ssa.FreeVar, *ssa.Global:
+		return v.Name()
+	case *ssa.UnOp:
+		if g, ok := v.X.(*ssa.Global); ok && v.Op == token.MUL {
+			return g.Name()
+		}
+	}
+	return v.String()
+}
@@ -0,0 +1,257 @@
+package knil
+
+// This file contains processes for tracking zero values
+// in freshly allocated variables such as &T{}, new(T),
+// arrays and make([]*T, n). Their pointer, map and function
+// fields and elements are nil until assigned, so loads of
+// them are known to be nil as long as nothing writes to them,
+// and the loads following the stores or the allocations in the
+// same block have the stored or zero values as long as nothing
+// else may write to them.
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// isZeroLoad reports whether v loads a field or an element
+// of a fresh allocation in the same function that is never
+// written, and so v holds the zero value of the type.
+func isZeroLoad(v *ssa.UnOp) bool {
+	if v.Op != token.MUL {
+		return false
+	}
+	addr, path := addrPath(v.X)
+	if len(path) == 0 {
+		return false
+	}
+	if !isFresh(addr) {
+		return false
+	}
+	return !mayBeWritten(addr, path)
+}
+
+// addrPath returns the base address of addr and the path of
+// the selections and indexing from it to addr.
+func addrPath(addr ssa.Value) (ssa.Value, []ssa.Value) {
+	var path []ssa.Value
+	for {
+		switch a := addr.(type) {
+		case *ssa.FieldAddr:
+			path = append([]ssa.Value{a}, path...)
+			addr = a.X
+		case *ssa.IndexAddr:
+			path = append([]ssa.Value{a}, path...)
+			addr = a.X
+		default:
+			return addr, path
+		}
+	}
+}
+
+// isFresh reports whether addr is a fresh allocation
+// whose fields and elements are zero.
+func isFresh(addr ssa.Value) bool {
+	switch a := addr.(type) {
+	case *ssa.Alloc:
+		return true
+	case *ssa.MakeSlice:
+		// Indexing empty slices panics with index out of
+		// range before the elements are used.
+		if c, ok := a.Len.(*ssa.Const); ok && c.Int64() == 0 {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// mayBeWritten reports whether the memory at the end of
+// path from addr may be written or escape.
+// Elements of the same array or slice are considered to
+// overlap because the indices are not tracked.
+func mayBeWritten(addr ssa.Value, path []ssa.Value) bool {
+	rs := addr.Referrers()
+	if rs == nil {
+		return true
+	}
+	for _, r := range *rs {
+		switch r := r.(type) {
+		case *ssa.DebugRef:
+			continue
+		case *ssa.UnOp:
+			if r.Op == token.MUL {
+				continue
+			}
+		case *ssa.Call:
+			// len and cap do not write elements.
+			if b, ok := r.Call.Value.(*ssa.Builtin); ok && (b.Name() == "len" || b.Name() == "cap") {
+				continue
+			}
+		case *ssa.FieldAddr:
+			if len(path) == 0 {
+				break
+			}
+			// Other fields do not overlap with the path.
+			if fa, ok := path[0].(*ssa.FieldAddr); ok && fa.Field != r.Field {
+				continue
+			}
+			if !mayBeWritten(r, path[1:]) {
+				continue
+			}
+		case *ssa.IndexAddr:
+			if len(path) == 0 {
+				break
+			}
+			if !mayBeWritten(r, path[1:]) {
+				continue
+			}
+		}
+		// The memory is stored, or the address escapes
+		// to other functions or values.
+		return true
+	}
+	return false
+}
+
+// storedValue returns the value of the field or the element of
+// a fresh allocation loaded by v, if it is stored before v in the
+// block of v, or the allocation is in the block and the memory
+// is still zero, and nothing else may write it before v. It
+// returns nil otherwise.
+func storedValue(v *ssa.UnOp) ssa.Value {
+	if v.Op != token.MUL {
+		return nil
+	}
+	base, path := addrPath(v.X)
+	if len(path) == 0 || !isFresh(base) {
+		return nil
+	}
+	escaped := escapes(base)
+	instrs := v.Block().Instrs
+	i := len(instrs) - 1
+	for ; i >= 0 && instrs[i] != ssa.Instruction(v); i-- {
+	}
+	for i--; i >= 0; i-- {
+		switch instr := instrs[i].(type) {
+		case *ssa.Store:
+			b, p := addrPath(instr.Addr)
+			if b != base {
+				if escaped {
+					// The stored memory may alias the loaded one.
+					return nil
+				}
+				continue
+			}
+			switch comparePaths(p, path) {
+			case samePath:
+				return instr.Val
+			case overlappingPaths:
+				return nil
+			}
+		case *ssa.Call, *ssa.Go, *ssa.Defer, *ssa.Send, *ssa.MapUpdate:
+			// The code run by them may write the escaped memory.
+			if escaped {
+				return nil
+			}
+		}
+		if instrs[i] == base.(ssa.Instruction) {
+			if !isNillable(v.Type()) {
+				return nil
+			}
+			return ssa.NewConst(nil, v.Type())
+		}
+	}
+	return nil
+}
+
+const (
+	disjointPaths = iota
+	samePath
+	overlappingPaths
+)
+
+// comparePaths reports whether the paths from the same base
+// address are the same, disjoint or may be overlapping.
+// Indexing of the same constants or values are the same,
+// while the other indexing may overlap.
+func comparePaths(p, q []ssa.Value) int {
+	for i := 0; i < len(p) && i < len(q); i++ {
+		switch a := p[i].(type) {
+		case *ssa.FieldAddr:
+			if a.Field != q[i].(*ssa.FieldAddr).Field {
+				return disjointPaths
+			}
+		case *ssa.IndexAddr:
+			b := q[i].(*ssa.IndexAddr)
+			if a.Index == b.Index {
+				continue
+			}
+			x, ok := a.Index.(*ssa.Const)
+			y, ok2 := b.Index.(*ssa.Const)
+			if !ok || !ok2 {
+				return overlappingPaths
+			}
+			if x.Int64() != y.Int64() {
+				return disjointPaths
+			}
+		}
+	}
+	if len(p) == len(q) {
+		return samePath
+	}
+	return overlappingPaths
+}
+
+// escapes reports whether the address of addr or of the memory
+// in it is used other than by loads and stores, so that other
+// code may write the memory.
+func escapes(addr ssa.Value) bool {
+	rs := addr.Referrers()
+	if rs == nil {
+		return true
+	}
+	for _, r := range *rs {
+		switch r := r.(type) {
+		case *ssa.DebugRef:
+			continue
+		case *ssa.UnOp:
+			if r.Op == token.MUL {
+				continue
+			}
+		case *ssa.Store:
+			if r.Addr == addr && r.Val != addr {
+				continue
+			}
+		case *ssa.FieldAddr:
+			if !escapes(r) {
+				continue
+			}
+		case *ssa.IndexAddr:
+			if !escapes(r) {
+				continue
+			}
+		case *ssa.Call:
+			// len and cap do not write elements.
+			if b, ok := r.Call.Value.(*ssa.Builtin); ok && (b.Name() == "len" || b.Name() == "cap") {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isNillable reports whether the values of t can be nil.
+func isNillable(t types.Type) bool {
+	switch t := t.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Chan, *types.Signature,
+		*types.Slice, *types.Interface:
+		return true
+	case *types.Basic:
+		return t.Kind() == types.UnsafePointer
+	}
+	return false
+}
@@ -1,8 +1,55 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/Matts966/knil/analyzer/knil"
 	"github.com/Matts966/knil/fullchecker"
 )
 
-func main() { fullchecker.Main(knil.Analyzer) }
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "why" {
+		os.Args = whyArgs(os.Args[0], os.Args[2:])
+	}
+	for _, c := range knil.Categories() {
+		fullchecker.AddRules(knil.Analyzer, fullchecker.Rule{
+			ID:          c.Name,
+			Severity:    c.Severity,
+			Description: c.Description,
+			Nilness:     c.Nilness,
+		})
+	}
+	fullchecker.Main(knil.Analyzer)
+}
+
+// whyArgs returns the arguments of the checker for the subcommand
+//
+//	knil why [-flag] file.go:LINE:COL
+//
+// which reports the nilness inferred for the values at the
+// position with the facts leading to it, analyzing the
+// package of the file.
+func whyArgs(name string, args []string) []string {
+	if len(args) == 0 || strings.HasPrefix(args[len(args)-1], "-") {
+		fmt.Fprintf(os.Stderr, "Usage: %s why [-flag] file.go:LINE:COL\n", name)
+		os.Exit(1)
+	}
+	pos := args[len(args)-1]
+	file := pos
+	for i := 0; i < 2; i++ {
+		j := strings.LastIndex(file, ":")
+		if j < 0 {
+			fmt.Fprintf(os.Stderr, "%s: invalid position %s, want file.go:LINE:COL\n", name, pos)
+			os.Exit(1)
+		}
+		file = file[:j]
+	}
+	dir := filepath.Dir(file)
+	if !filepath.IsAbs(dir) && !strings.HasPrefix(dir, ".") {
+		dir = "./" + filepath.ToSlash(dir)
+	}
+	return append(append([]string{name}, args[:len(args)-1]...), "-why="+pos, dir)
+}
@@ -36,6 +36,15 @@ import (
 	"golang.org/x/tools/go/analysis/unitchecker"
 )
 
+// A Rule describes a category of the diagnostics of an analyzer,
+// such as a rule in the SARIF output.
+type Rule = analysisflags.Rule
+
+// AddRules adds the rules of the categories of the diagnostics of a.
+func AddRules(a *analysis.Analyzer, rules ...Rule) {
+	analysisflags.Rules[a.Name] = append(analysisflags.Rules[a.Name], rules...)
+}
+
 // Main is the main function for a checker command for a single analysis.
 func Main(a *analysis.Analyzer) {
 	log.SetFlags(0)
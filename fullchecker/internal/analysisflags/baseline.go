@@ -0,0 +1,95 @@
+package analysisflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A Baseline is a set of findings recorded with -write-baseline,
+// which are not reported with -baseline.
+type Baseline struct {
+	Version  int       `json:"version"`
+	Findings []Finding `json:"findings"`
+}
+
+// A Finding is a diagnostic recorded in a baseline. It is identified
+// by the fingerprint, and the other fields are for the readers.
+type Finding struct {
+	Fingerprint string `json:"fingerprint"`
+	Analyzer    string `json:"analyzer"`
+	Category    string `json:"category,omitempty"`
+	File        string `json:"file"`
+	Function    string `json:"function,omitempty"`
+	Message     string `json:"message"`
+}
+
+func (f Finding) String() string {
+	s := f.File
+	if f.Function != "" {
+		s += ": " + f.Function
+	}
+	category := f.Category
+	if category == "" {
+		category = f.Analyzer
+	}
+	return fmt.Sprintf("%s: %s: %s", s, category, f.Message)
+}
+
+// Finding returns the finding of the diagnostic of the analyzer name,
+// whose fingerprint is computed like Fingerprint.
+func (fp *Fingerprinter) Finding(fset *token.FileSet, name string, diag analysis.Diagnostic) Finding {
+	posn := fset.Position(diag.Pos)
+	return Finding{
+		Fingerprint: fp.Fingerprint(fset, name, diag),
+		Analyzer:    name,
+		Category:    diag.Category,
+		File:        RelPath(posn.Filename),
+		Function:    fp.EnclosingFunc(posn),
+		Message:     diag.Message,
+	}
+}
+
+// ReadBaseline reads the baseline file.
+func ReadBaseline(file string) (*Baseline, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if b.Version != 1 {
+		return nil, fmt.Errorf("%s: unknown baseline version %d", file, b.Version)
+	}
+	return &b, nil
+}
+
+// Write writes the baseline to the file, with the findings
+// sorted by file, function, category and message to keep the
+// differences of the revisions small.
+func (b *Baseline) Write(file string) error {
+	sort.SliceStable(b.Findings, func(i, j int) bool {
+		x, y := b.Findings[i], b.Findings[j]
+		if x.File != y.File {
+			return x.File < y.File
+		}
+		if x.Function != y.Function {
+			return x.Function < y.Function
+		}
+		if x.Category != y.Category {
+			return x.Category < y.Category
+		}
+		return x.Message < y.Message
+	})
+	data, err := json.MarshalIndent(b, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
@@ -0,0 +1,102 @@
+package analysisflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"log"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A CodeClimateReport is a report of the diagnostics in the Code
+// Climate format of the GitLab Code Quality reports, whose issues
+// are checks named by the categories with the severities of them.
+type CodeClimateReport struct {
+	issues []codeClimateIssue
+	fp     *Fingerprinter
+	seen   map[string]bool // the keys of the added diagnostics
+}
+
+type codeClimateIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Categories  []string            `json:"categories"`
+	Severity    string              `json:"severity"`
+	Fingerprint string              `json:"fingerprint"`
+	Location    codeClimateLocation `json:"location"`
+}
+
+type codeClimateLocation struct {
+	Path  string           `json:"path"`
+	Lines codeClimateLines `json:"lines"`
+}
+
+type codeClimateLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end"`
+}
+
+func NewCodeClimateReport() *CodeClimateReport {
+	return &CodeClimateReport{
+		issues: []codeClimateIssue{},
+		fp:     NewFingerprinter(),
+		seen:   make(map[string]bool),
+	}
+}
+
+// codeClimateSeverity returns the Code Climate severity of the severity.
+func codeClimateSeverity(severity string) string {
+	switch severity {
+	case "error":
+		return "critical"
+	case "info":
+		return "info"
+	}
+	return "major"
+}
+
+// Add adds the diagnostics of analysis 'name'.
+func (r *CodeClimateReport) Add(fset *token.FileSet, name string, diags []analysis.Diagnostic) {
+	for _, diag := range diags {
+		category := diag.Category
+		if category == "" {
+			category = name
+		}
+		// De-duplicate the diagnostics in source files that
+		// belong to multiple packages, such as foo and foo.test.
+		posn, end := fset.Position(diag.Pos), fset.Position(diag.End)
+		key := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s", name, category, posn, end, diag.Message)
+		if r.seen[key] {
+			continue
+		}
+		r.seen[key] = true
+
+		rule, _ := ruleOf(name, diag.Category)
+		lines := codeClimateLines{posn.Line, posn.Line}
+		if end.IsValid() && end.Filename == posn.Filename {
+			lines.End = end.Line
+		}
+		r.issues = append(r.issues, codeClimateIssue{
+			Type:        "issue",
+			CheckName:   fmt.Sprintf("%s/%s", name, category),
+			Description: diag.Message,
+			Categories:  []string{"Bug Risk"},
+			Severity:    codeClimateSeverity(rule.Severity),
+			Fingerprint: r.fp.Fingerprint(fset, name, diag),
+			Location: codeClimateLocation{
+				Path:  RelPath(posn.Filename),
+				Lines: lines,
+			},
+		})
+	}
+}
+
+func (r *CodeClimateReport) Print() {
+	data, err := json.MarshalIndent(r.issues, "", "\t")
+	if err != nil {
+		log.Panicf("internal error: JSON marshalling failed: %v", err)
+	}
+	fmt.Printf("%s\n", data)
+}
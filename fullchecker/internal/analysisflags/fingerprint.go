@@ -0,0 +1,154 @@
+package analysisflags
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A Fingerprinter computes the fingerprints of diagnostics, which
+// identify them across line shifts by the analyzer, the category,
+// the enclosing function, the message and the source lines
+// normalized in white space, with the number of the preceding
+// diagnostics of the same fingerprint in the file to tell them
+// apart.
+type Fingerprinter struct {
+	fset    *token.FileSet // for parsing the source files
+	sources map[string]*source
+	seen    map[string]int
+}
+
+// A source is a source file read for fingerprints.
+type source struct {
+	content []byte
+	file    *ast.File // nil if it can't be parsed
+}
+
+func NewFingerprinter() *Fingerprinter {
+	return &Fingerprinter{
+		fset:    token.NewFileSet(),
+		sources: make(map[string]*source),
+		seen:    make(map[string]int),
+	}
+}
+
+// Fingerprint returns the fingerprint of the diagnostic of the
+// analyzer name, which must be computed once for each diagnostic
+// in a deterministic order.
+func (fp *Fingerprinter) Fingerprint(fset *token.FileSet, name string, diag analysis.Diagnostic) string {
+	posn := fset.Position(diag.Pos)
+	category := diag.Category
+	if category == "" {
+		category = name
+	}
+	key := strings.Join([]string{
+		name,
+		category,
+		RelPath(posn.Filename),
+		fp.EnclosingFunc(posn),
+		diag.Message,
+		fp.Snippet(fset, diag),
+	}, "\x00")
+	n := fp.seen[key]
+	fp.seen[key]++
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", key, n))))[:32]
+}
+
+// RelPath returns the slash-separated path of the file relative
+// to the current directory if it is in it, or the path as is.
+func RelPath(name string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return name
+	}
+	rel, err := filepath.Rel(wd, name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return name
+	}
+	return filepath.ToSlash(rel)
+}
+
+// source returns the source file of the name, which
+// is empty if it can't be read.
+func (fp *Fingerprinter) source(name string) *source {
+	s, ok := fp.sources[name]
+	if !ok {
+		s = new(source)
+		s.content, _ = ioutil.ReadFile(name)
+		if s.content != nil {
+			s.file, _ = parser.ParseFile(fp.fset, name, s.content, 0)
+		}
+		fp.sources[name] = s
+	}
+	return s
+}
+
+// EnclosingFunc returns the name of the function declaration
+// enclosing posn, such as f or (*T).m, or empty if there is none.
+// The function literals are named by the declarations enclosing
+// them.
+func (fp *Fingerprinter) EnclosingFunc(posn token.Position) string {
+	s := fp.source(posn.Filename)
+	if s.file == nil {
+		return ""
+	}
+	tf := fp.fset.File(s.file.Pos())
+	if posn.Offset < 0 || posn.Offset > tf.Size() {
+		return ""
+	}
+	pos := tf.Pos(posn.Offset)
+	for _, d := range s.file.Decls {
+		d, ok := d.(*ast.FuncDecl)
+		if !ok || pos < d.Pos() || d.End() <= pos {
+			continue
+		}
+		if d.Recv == nil || len(d.Recv.List) == 0 {
+			return d.Name.Name
+		}
+		recv := d.Recv.List[0].Type
+		var star string
+		if s, ok := recv.(*ast.StarExpr); ok {
+			recv, star = s.X, "*"
+		}
+		if id, ok := recv.(*ast.Ident); ok {
+			return fmt.Sprintf("(%s%s).%s", star, id.Name, d.Name.Name)
+		}
+		return d.Name.Name
+	}
+	return ""
+}
+
+// Snippet returns the source text of the lines of the diagnostic,
+// from the start of the line of its position to the end of the line
+// of its end, with the spaces trimmed and folded. The whole lines
+// tell apart the diagnostics of the same expression, such as a
+// field name, in different statements.
+func (fp *Fingerprinter) Snippet(fset *token.FileSet, diag analysis.Diagnostic) string {
+	posn, end := fset.Position(diag.Pos), fset.Position(diag.End)
+	content := fp.source(posn.Filename).content
+	if posn.Offset < 0 || posn.Offset > len(content) {
+		return ""
+	}
+	start := posn.Offset - (posn.Column - 1)
+	if start < 0 {
+		start = 0
+	}
+	stop := posn.Offset
+	if end.IsValid() && end.Filename == posn.Filename && posn.Offset < end.Offset && end.Offset <= len(content) {
+		stop = end.Offset
+	}
+	if i := strings.IndexByte(string(content[stop:]), '\n'); i >= 0 {
+		stop += i
+	} else {
+		stop = len(content)
+	}
+	return strings.Join(strings.Fields(string(content[start:stop])), " ")
+}
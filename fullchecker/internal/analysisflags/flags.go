@@ -25,10 +25,15 @@ import (
 
 // flags common to all {single,multi,unit}checkers.
 var (
-	JSON    = false // -json
-	Context = -1    // -c=N: if N>0, display offending line plus N lines of context
+	JSON        = false // -json
+	Context     = -1    // -c=N: if N>0, display offending line plus N lines of context
+	Format      = ""    // -format=F: output format, "text", "json", "sarif", "github" or "codeclimate"
+	JSONVersion = 1     // -json-version=N: version of the JSON output, 1 or 2
 )
 
+// formats holds the output formats.
+var formats = []string{"text", "json", "sarif", "github", "codeclimate"}
+
 // Parse creates a flag for each of the analyzer's flags,
 // including (in multi mode) a flag named after the analyzer,
 // parses the flags, then filters and returns the list of
@@ -75,6 +80,8 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 	// flags common to all checkers
 	flag.BoolVar(&JSON, "json", JSON, "emit JSON output")
 	flag.IntVar(&Context, "c", Context, `display offending line with this many lines of context`)
+	flag.StringVar(&Format, "format", Format, `output format, one of "`+strings.Join(formats, `", "`)+`" (-json is -format=json)`)
+	flag.IntVar(&JSONVersion, "json-version", JSONVersion, "version of the JSON output, 1 or 2 with ranges, functions, nilness and fixes (implies -json)")
 
 	// Add shims for legacy vet flags to enable existing
 	// scripts that run vet to continue to work.
@@ -91,6 +98,10 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 
 	flag.Parse() // (ExitOnError)
 
+	if err := parseFormat(); err != nil {
+		log.Fatal(err)
+	}
+
 	// -flags: print flags so that go vet knows which ones are legitimate.
 	if *printflags {
 		printFlags()
@@ -159,6 +170,36 @@ func expand(analyzers []*analysis.Analyzer) map[*analysis.Analyzer]bool {
 	return seen
 }
 
+// parseFormat validates the output format and the JSON version,
+// and sets JSON if the format is "json".
+func parseFormat() error {
+	if JSONVersion != 1 && JSONVersion != 2 {
+		return fmt.Errorf("unknown JSON version %d", JSONVersion)
+	}
+	if JSONVersion != 1 {
+		if Format != "" && Format != "json" {
+			return fmt.Errorf("-json-version conflicts with -format=%s", Format)
+		}
+		JSON = true
+	}
+	if JSON {
+		if Format != "" && Format != "json" {
+			return fmt.Errorf("-json conflicts with -format=%s", Format)
+		}
+		Format = "json"
+	}
+	if Format == "" {
+		Format = "text"
+	}
+	for _, f := range formats {
+		if f == Format {
+			JSON = Format == "json"
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q", Format)
+}
+
 func printFlags() {
 	type jsonFlag struct {
 		Name  string
@@ -168,10 +209,10 @@ func printFlags() {
 	var flags []jsonFlag = nil
 	flag.VisitAll(func(f *flag.Flag) {
 		// Don't report {single,multi}checker debugging
-		// flags or fix as these have no effect on unitchecker
-		// (as invoked by 'go vet').
+		// flags or the fix, output and baseline flags as these
+		// have no effect on unitchecker (as invoked by 'go vet').
 		switch f.Name {
-		case "debug", "cpuprofile", "memprofile", "trace", "fix":
+		case "debug", "cpuprofile", "memprofile", "trace", "fix", "diff", "format", "json-version", "baseline", "write-baseline", "new-from-rev":
 			return
 		}
 
@@ -320,6 +361,9 @@ var vetLegacyFlags = map[string]string{
 func PrintPlain(fset *token.FileSet, diag analysis.Diagnostic) {
 	posn := fset.Position(diag.Pos)
 	fmt.Fprintf(os.Stderr, "%s: %s\n", posn, diag.Message)
+	for _, r := range diag.Related {
+		fmt.Fprintf(os.Stderr, "\t%s: %s\n", fset.Position(r.Pos), r.Message)
+	}
 
 	// -c=N: show offending line plus N lines of context.
 	if Context >= 0 {
@@ -352,19 +396,32 @@ func (tree JSONTree) Add(fset *token.FileSet, id, name string, diags []analysis.
 		}
 		v = jsonError{err.Error()}
 	} else if len(diags) > 0 {
+		type jsonRelated struct {
+			Posn    string `json:"posn"`
+			Message string `json:"message"`
+		}
 		type jsonDiagnostic struct {
-			Category string `json:"category,omitempty"`
-			Posn     string `json:"posn"`
-			Message  string `json:"message"`
+			Category string        `json:"category,omitempty"`
+			Posn     string        `json:"posn"`
+			Message  string        `json:"message"`
+			Related  []jsonRelated `json:"related,omitempty"`
 		}
 		var diagnostics []jsonDiagnostic
-		// TODO(matloob): Should the JSON diagnostics contain ranges?
-		// If so, how should they be formatted?
+		// The ranges are in the version 2 format (see JSONTreeV2),
+		// keeping this one as is for the existing tools.
 		for _, f := range diags {
+			var related []jsonRelated
+			for _, r := range f.Related {
+				related = append(related, jsonRelated{
+					Posn:    fset.Position(r.Pos).String(),
+					Message: r.Message,
+				})
+			}
 			diagnostics = append(diagnostics, jsonDiagnostic{
 				Category: f.Category,
 				Posn:     fset.Position(f.Pos).String(),
 				Message:  f.Message,
+				Related:  related,
 			})
 		}
 		v = diagnostics
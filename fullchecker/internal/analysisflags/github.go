@@ -0,0 +1,69 @@
+package analysisflags
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// githubCommand returns the level of the GitHub Actions workflow
+// command annotating the diagnostics of the severity.
+func githubCommand(severity string) string {
+	switch severity {
+	case "error", "warning":
+		return severity
+	case "info":
+		return "notice"
+	}
+	return "warning"
+}
+
+// PrintGitHub prints a diagnostic of analysis 'name' as a GitHub
+// Actions workflow command, which annotates the range of it with the
+// level of the severity of the category and the related locations.
+func PrintGitHub(fset *token.FileSet, name string, diag analysis.Diagnostic) {
+	category := diag.Category
+	if category == "" {
+		category = name
+	}
+	rule, _ := ruleOf(name, diag.Category)
+	posn := fset.Position(diag.Pos)
+	props := []string{
+		"file=" + githubProperty(RelPath(posn.Filename)),
+		fmt.Sprintf("line=%d", posn.Line),
+		fmt.Sprintf("col=%d", posn.Column),
+	}
+	if end := fset.Position(diag.End); end.IsValid() && end.Filename == posn.Filename {
+		props = append(props, fmt.Sprintf("endLine=%d", end.Line))
+		if end.Line == posn.Line {
+			props = append(props, fmt.Sprintf("endColumn=%d", end.Column))
+		}
+	}
+	props = append(props, "title="+githubProperty(fmt.Sprintf("%s (%s)", name, category)))
+
+	message := diag.Message
+	for _, r := range diag.Related {
+		rel := fset.Position(r.Pos)
+		rel.Filename = RelPath(rel.Filename)
+		message += fmt.Sprintf("\n%s: %s", rel, r.Message)
+	}
+	fmt.Printf("::%s %s::%s\n", githubCommand(rule.Severity), strings.Join(props, ","), githubData(message))
+}
+
+// PrintGitHubError prints an error of analysis 'name' on
+// package 'id' as a GitHub Actions workflow command.
+func PrintGitHubError(id, name string, err error) {
+	fmt.Printf("::error title=%s::%s\n", githubProperty(name), githubData(fmt.Sprintf("%s: %v", id, err)))
+}
+
+// githubData escapes the message of a workflow command.
+func githubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubProperty escapes a property value of a workflow command.
+func githubProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(githubData(s))
+}
@@ -0,0 +1,143 @@
+package analysisflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"log"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A JSONTreeV2 is the version 2 of the JSON output (-json-version=2),
+// a mapping from package ID to analysis name to result like JSONTree,
+// whose diagnostics also hold their ranges, the enclosing functions,
+// the nilness of the values, and the related locations and suggested
+// fixes with their ranges.
+type JSONTreeV2 struct {
+	Version  int                               `json:"version"`
+	Packages map[string]map[string]interface{} `json:"packages"`
+
+	fp *Fingerprinter
+}
+
+type jsonPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Offset int    `json:"offset"`
+}
+
+type jsonRelatedV2 struct {
+	Posn    string       `json:"posn"`
+	Start   jsonPosition `json:"start"`
+	End     jsonPosition `json:"end"`
+	Message string       `json:"message"`
+}
+
+type jsonTextEdit struct {
+	Start   jsonPosition `json:"start"`
+	End     jsonPosition `json:"end"`
+	NewText string       `json:"new_text"`
+}
+
+type jsonSuggestedFix struct {
+	Message string         `json:"message"`
+	Edits   []jsonTextEdit `json:"edits"`
+}
+
+type jsonDiagnosticV2 struct {
+	Category string       `json:"category,omitempty"`
+	Posn     string       `json:"posn"`
+	Start    jsonPosition `json:"start"`
+	End      jsonPosition `json:"end"`
+	// Function is the function declaration enclosing the diagnostic.
+	Function string `json:"function,omitempty"`
+	// Nilness is the nilness of the value of the diagnostic,
+	// "nil" or "unknown", if it is known by the category.
+	Nilness        string             `json:"nilness,omitempty"`
+	Message        string             `json:"message"`
+	Related        []jsonRelatedV2    `json:"related,omitempty"`
+	SuggestedFixes []jsonSuggestedFix `json:"suggested_fixes,omitempty"`
+}
+
+func NewJSONTreeV2() *JSONTreeV2 {
+	return &JSONTreeV2{
+		Version:  2,
+		Packages: make(map[string]map[string]interface{}),
+		fp:       NewFingerprinter(),
+	}
+}
+
+// jsonRange returns the positions of the range from pos to end,
+// which is empty at pos if end is not valid.
+func jsonRange(fset *token.FileSet, pos, end token.Pos) (jsonPosition, jsonPosition) {
+	posn := fset.Position(pos)
+	start := jsonPosition{posn.Filename, posn.Line, posn.Column, posn.Offset}
+	if !end.IsValid() {
+		return start, start
+	}
+	endPosn := fset.Position(end)
+	return start, jsonPosition{endPosn.Filename, endPosn.Line, endPosn.Column, endPosn.Offset}
+}
+
+// Add adds the result of analysis 'name' on package 'id'.
+// The result is either a list of diagnostics or an error.
+func (tree *JSONTreeV2) Add(fset *token.FileSet, id, name string, diags []analysis.Diagnostic, err error) {
+	var v interface{}
+	if err != nil {
+		type jsonError struct {
+			Err string `json:"error"`
+		}
+		v = jsonError{err.Error()}
+	} else if len(diags) > 0 {
+		var diagnostics []jsonDiagnosticV2
+		for _, f := range diags {
+			d := jsonDiagnosticV2{
+				Category: f.Category,
+				Posn:     fset.Position(f.Pos).String(),
+				Function: tree.fp.EnclosingFunc(fset.Position(f.Pos)),
+				Message:  f.Message,
+			}
+			d.Start, d.End = jsonRange(fset, f.Pos, f.End)
+			if r, ok := ruleOf(name, f.Category); ok {
+				d.Nilness = r.Nilness
+			}
+			for _, r := range f.Related {
+				rel := jsonRelatedV2{
+					Posn:    fset.Position(r.Pos).String(),
+					Message: r.Message,
+				}
+				rel.Start, rel.End = jsonRange(fset, r.Pos, r.End)
+				d.Related = append(d.Related, rel)
+			}
+			for _, fix := range f.SuggestedFixes {
+				sf := jsonSuggestedFix{Message: fix.Message, Edits: []jsonTextEdit{}}
+				for _, e := range fix.TextEdits {
+					edit := jsonTextEdit{NewText: string(e.NewText)}
+					edit.Start, edit.End = jsonRange(fset, e.Pos, e.End)
+					sf.Edits = append(sf.Edits, edit)
+				}
+				d.SuggestedFixes = append(d.SuggestedFixes, sf)
+			}
+			diagnostics = append(diagnostics, d)
+		}
+		v = diagnostics
+	}
+	if v != nil {
+		m, ok := tree.Packages[id]
+		if !ok {
+			m = make(map[string]interface{})
+			tree.Packages[id] = m
+		}
+		m[name] = v
+	}
+}
+
+func (tree *JSONTreeV2) Print() {
+	data, err := json.MarshalIndent(tree, "", "\t")
+	if err != nil {
+		log.Panicf("internal error: JSON marshalling failed: %v", err)
+	}
+	fmt.Printf("%s\n", data)
+}
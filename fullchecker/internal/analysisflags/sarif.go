@@ -0,0 +1,297 @@
+package analysisflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Matts966/knil/fullchecker/internal/span"
+	"golang.org/x/tools/go/analysis"
+)
+
+// A Rule describes a category of the diagnostics of an analyzer.
+type Rule struct {
+	ID string
+	// Severity is the severity of the diagnostics,
+	// "info", "warning" or "error".
+	Severity    string
+	Description string
+	// Nilness is the nilness of the values of the diagnostics,
+	// "nil", "unknown" or empty if it is not known.
+	Nilness string
+}
+
+// Rules holds the rules of the analyzers by name.
+var Rules = make(map[string][]Rule)
+
+// ruleOf returns the rule of the category of the analyzer name,
+// which is the name itself if the category is empty.
+func ruleOf(name, category string) (Rule, bool) {
+	if category == "" {
+		category = name
+	}
+	for _, r := range Rules[name] {
+		if r.ID == category {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// srcroot is the base of the URIs relative to the current directory.
+const srcroot = "%SRCROOT%"
+
+// A SARIFLog is a log of the results of the analyses in the Static
+// Analysis Results Interchange Format (SARIF) 2.1.0, with a run for
+// each analysis.
+type SARIFLog struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*sarifRun `json:"runs"`
+
+	runs map[string]*sarifRun
+	fp   *Fingerprinter
+	seen map[string]bool // the keys of the added diagnostics
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	Invocations        []*sarifInvocation               `json:"invocations"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	ColumnKind         string                           `json:"columnKind"`
+	Results            []sarifResult                    `json:"results"`
+
+	ruleIndex map[string]int
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	ShortDescription     *sarifMessage       `json:"shortDescription,omitempty"`
+	DefaultConfiguration *sarifConfiguration `json:"defaultConfiguration,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	RelatedLocations    []sarifLocation   `json:"relatedLocations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+	ByteOffset  int `json:"byteOffset"`
+	ByteLength  int `json:"byteLength,omitempty"`
+}
+
+func NewSARIFLog() *SARIFLog {
+	return &SARIFLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []*sarifRun{},
+		runs:    make(map[string]*sarifRun),
+		fp:      NewFingerprinter(),
+		seen:    make(map[string]bool),
+	}
+}
+
+// sarifLevel returns the SARIF level of the severity.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "error", "warning":
+		return severity
+	case "info":
+		return "note"
+	}
+	return "warning"
+}
+
+// run returns the run of the analysis name,
+// with the rules of the analysis.
+func (l *SARIFLog) run(name string) *sarifRun {
+	r, ok := l.runs[name]
+	if ok {
+		return r
+	}
+	r = &sarifRun{
+		Tool:        sarifTool{Driver: sarifDriver{Name: name, Rules: []sarifRule{}}},
+		Invocations: []*sarifInvocation{{ExecutionSuccessful: true}},
+		ColumnKind:  "unicodeCodePoints",
+		Results:     []sarifResult{},
+		ruleIndex:   make(map[string]int),
+	}
+	if wd, err := os.Getwd(); err == nil {
+		r.OriginalURIBaseIDs = map[string]sarifArtifactLocation{
+			srcroot: {URI: strings.TrimSuffix(string(span.URIFromPath(wd)), "/") + "/"},
+		}
+	}
+	for _, rule := range Rules[name] {
+		r.ruleIndex[rule.ID] = len(r.Tool.Driver.Rules)
+		r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     &sarifMessage{rule.Description},
+			DefaultConfiguration: &sarifConfiguration{sarifLevel(rule.Severity)},
+		})
+	}
+	l.runs[name] = r
+	l.Runs = append(l.Runs, r)
+	return r
+}
+
+// AddRun adds the run of analysis 'name' even if it has no results.
+func (l *SARIFLog) AddRun(name string) {
+	l.run(name)
+}
+
+// Add adds the result of analysis 'name' on package 'id'.
+// The result is either a list of diagnostics or an error.
+func (l *SARIFLog) Add(fset *token.FileSet, id, name string, diags []analysis.Diagnostic, err error) {
+	if err == nil && len(diags) == 0 {
+		return
+	}
+	r := l.run(name)
+	if err != nil {
+		inv := r.Invocations[0]
+		inv.ExecutionSuccessful = false
+		inv.ToolExecutionNotifications = append(inv.ToolExecutionNotifications, sarifNotification{
+			Level:   "error",
+			Message: sarifMessage{fmt.Sprintf("%s: %v", id, err)},
+		})
+		return
+	}
+	for _, diag := range diags {
+		ruleID := diag.Category
+		if ruleID == "" {
+			ruleID = name
+		}
+		// De-duplicate the diagnostics in source files that
+		// belong to multiple packages, such as foo and foo.test.
+		key := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s", name, ruleID, fset.Position(diag.Pos), fset.Position(diag.End), diag.Message)
+		if l.seen[key] {
+			continue
+		}
+		l.seen[key] = true
+
+		index, ok := r.ruleIndex[ruleID]
+		if !ok {
+			index = len(r.Tool.Driver.Rules)
+			r.ruleIndex[ruleID] = index
+			r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, sarifRule{ID: ruleID})
+		}
+		level := "warning"
+		if c := r.Tool.Driver.Rules[index].DefaultConfiguration; c != nil {
+			level = c.Level
+		}
+		res := sarifResult{
+			RuleID:    ruleID,
+			RuleIndex: index,
+			Level:     level,
+			Message:   sarifMessage{diag.Message},
+			Locations: []sarifLocation{{PhysicalLocation: l.location(fset, diag.Pos, diag.End)}},
+			PartialFingerprints: map[string]string{
+				"knilFingerprint/v1": l.fp.Fingerprint(fset, name, diag),
+			},
+		}
+		for i, rel := range diag.Related {
+			res.RelatedLocations = append(res.RelatedLocations, sarifLocation{
+				ID:               i + 1,
+				PhysicalLocation: l.location(fset, rel.Pos, rel.End),
+				Message:          &sarifMessage{rel.Message},
+			})
+		}
+		r.Results = append(r.Results, res)
+	}
+}
+
+// location returns the physical location of the range
+// from pos to end, or of pos if end is not valid.
+func (l *SARIFLog) location(fset *token.FileSet, pos, end token.Pos) sarifPhysicalLocation {
+	posn := fset.Position(pos)
+	loc := sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: string(span.URIFromPath(posn.Filename))},
+		Region: sarifRegion{
+			StartLine:   posn.Line,
+			StartColumn: l.column(posn),
+			ByteOffset:  posn.Offset,
+		},
+	}
+	if rel := RelPath(posn.Filename); rel != posn.Filename {
+		loc.ArtifactLocation = sarifArtifactLocation{URI: rel, URIBaseID: srcroot}
+	}
+	if endPosn := fset.Position(end); end.IsValid() && endPosn.Filename == posn.Filename && endPosn.Offset >= posn.Offset {
+		loc.Region.EndLine = endPosn.Line
+		loc.Region.EndColumn = l.column(endPosn)
+		loc.Region.ByteLength = endPosn.Offset - posn.Offset
+	}
+	return loc
+}
+
+// column returns the column of posn in Unicode code points.
+func (l *SARIFLog) column(posn token.Position) int {
+	content := l.fp.source(posn.Filename).content
+	start := posn.Offset - (posn.Column - 1)
+	if start < 0 || posn.Offset > len(content) {
+		return posn.Column
+	}
+	return utf8.RuneCount(content[start:posn.Offset]) + 1
+}
+
+func (l *SARIFLog) Print() {
+	data, err := json.MarshalIndent(l, "", "\t")
+	if err != nil {
+		log.Panicf("internal error: SARIF marshalling failed: %v", err)
+	}
+	fmt.Printf("%s\n", data)
+}
@@ -20,6 +20,8 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"runtime/pprof"
@@ -31,6 +33,7 @@ import (
 
 	"github.com/Matts966/knil/fullchecker/internal/analysisflags"
 	"github.com/Matts966/knil/fullchecker/internal/analysisinternal"
+	"github.com/Matts966/knil/fullchecker/internal/diff"
 	"github.com/Matts966/knil/fullchecker/internal/span"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/packages"
@@ -52,6 +55,21 @@ var (
 
 	// Fix determines whether to apply all suggested fixes.
 	Fix bool
+
+	// Diff determines whether to print the unified diffs of all
+	// suggested fixes instead of applying them.
+	Diff bool
+
+	// Baseline is the file of the findings which are not reported.
+	Baseline string
+
+	// WriteBaseline is the file to record all the findings in.
+	WriteBaseline string
+
+	// NewFromRev is the git revision since whose merge base with
+	// HEAD the changed lines are the only ones whose diagnostics
+	// are reported.
+	NewFromRev string
 )
 
 // RegisterFlags registers command-line flags used by the analysis driver.
@@ -66,6 +84,10 @@ func RegisterFlags() {
 	flag.StringVar(&Trace, "trace", "", "write trace log to this file")
 
 	flag.BoolVar(&Fix, "fix", false, "apply all suggested fixes")
+	flag.BoolVar(&Diff, "diff", false, "print the unified diffs of all suggested fixes instead of applying them")
+	flag.StringVar(&Baseline, "baseline", "", "report only the findings not recorded in this file")
+	flag.StringVar(&WriteBaseline, "write-baseline", "", "record all the findings in this file")
+	flag.StringVar(&NewFromRev, "new-from-rev", "", "report only the diagnostics on the lines changed since the merge base of this git revision and HEAD")
 }
 
 // Run loads the packages specified by args using go/packages,
@@ -73,7 +95,8 @@ func RegisterFlags() {
 // Analysis flags must already have been set.
 // It provides most of the logic for the main functions of both the
 // singlechecker and the multi-analysis commands.
-// It returns the appropriate exit code.
+// It returns the appropriate exit code, which is 4 if Diff is
+// set and there are pending fixes unless the analysis failed.
 func Run(args []string, analyzers []*analysis.Analyzer) (exitcode int) {
 	if CPUProfile != "" {
 		f, err := os.Create(CPUProfile)
@@ -136,11 +159,18 @@ func Run(args []string, analyzers []*analysis.Analyzer) (exitcode int) {
 	// Print the results.
 	roots := analyze(initial, analyzers)
 
-	if Fix {
-		applyFixes(roots)
+	var fixcode int
+	if Fix || Diff {
+		fixcode = applyFixes(roots)
 	}
 
-	return printDiagnostics(roots)
+	exitcode = printDiagnostics(roots)
+	// The failures take precedence over the pending
+	// fixes, and the pending fixes over the diagnostics.
+	if exitcode != 1 && fixcode != 0 {
+		exitcode = fixcode
+	}
+	return exitcode
 }
 
 // load loads the initial packages.
@@ -264,21 +294,25 @@ func analyze(pkgs []*packages.Package, analyzers []*analysis.Analyzer) []*action
 	return roots
 }
 
-func applyFixes(roots []*action) {
+// applyFixes applies the suggested fixes of the diagnostics of the
+// root packages, or prints the unified diffs of them if Diff is set.
+// A fix with edits overlapping the ones of the fixes accepted before
+// is reported and skipped, while the other fixes are still applied.
+//
+// It returns the exitcode: 1 if a file can't be rewritten, 4 if Diff
+// is set and there are pending fixes, and 0 otherwise.
+func applyFixes(roots []*action) (exitcode int) {
 	visited := make(map[*action]bool)
-	var apply func(*action) error
-	var visitAll func(actions []*action) error
-	visitAll = func(actions []*action) error {
+	var apply func(*action)
+	var visitAll func(actions []*action)
+	visitAll = func(actions []*action) {
 		for _, act := range actions {
 			if !visited[act] {
 				visited[act] = true
 				visitAll(act.deps)
-				if err := apply(act); err != nil {
-					return err
-				}
+				apply(act)
 			}
 		}
-		return nil
 	}
 
 	// TODO(matloob): Is this tree business too complicated? (After all this is Go!)
@@ -292,65 +326,126 @@ func applyFixes(roots []*action) {
 		left, right *node
 	}
 
-	var insert func(tree **node, edit offsetedit) error
-	insert = func(treeptr **node, edit offsetedit) error {
+	same := func(x, y offsetedit) bool {
+		return x.start == y.start && x.end == y.end && bytes.Equal(x.newText, y.newText)
+	}
+	// overlapping returns the edit in the tree overlapping edit, or nil
+	// if there is none. An edit identical to edit is returned as well.
+	var overlapping func(tree *node, edit offsetedit) *offsetedit
+	overlapping = func(tree *node, edit offsetedit) *offsetedit {
+		if tree == nil {
+			return nil
+		}
+		if same(edit, tree.edit) {
+			return &tree.edit
+		}
+		if edit.end <= tree.edit.start {
+			return overlapping(tree.left, edit)
+		} else if edit.start >= tree.edit.end {
+			return overlapping(tree.right, edit)
+		}
+		return &tree.edit
+	}
+	var insert func(tree **node, edit offsetedit)
+	insert = func(treeptr **node, edit offsetedit) {
 		if *treeptr == nil {
 			*treeptr = &node{edit, nil, nil}
-			return nil
+			return
 		}
 		tree := *treeptr
 		if edit.end <= tree.edit.start {
-			return insert(&tree.left, edit)
-		} else if edit.start >= tree.edit.end {
-			return insert(&tree.right, edit)
+			insert(&tree.left, edit)
+		} else {
+			insert(&tree.right, edit)
 		}
+	}
 
-		// Overlapping text edit.
-		return fmt.Errorf("analyses applying overlapping text edits affecting pos range (%v, %v) and (%v, %v)",
-			edit.start, edit.end, tree.edit.start, tree.edit.end)
+	// The edits are keyed by the file name, as a file may belong to
+	// multiple packages such as foo and foo.test.
+	editsForFile := make(map[string]*node)
 
+	// fileEdit is an edit of the file named name.
+	type fileEdit struct {
+		name string
+		offsetedit
 	}
+	// accept validates the edits of the fix and adds them to the
+	// trees unless they overlap the edits accepted before. The edits
+	// identical to the ones accepted before are dropped, as the same
+	// diagnostics are reported in each package sharing a file.
+	accept := func(act *action, sf analysis.SuggestedFix) error {
+		var edits []fileEdit
+		for _, edit := range sf.TextEdits {
+			// Validate the edit.
+			if edit.Pos > edit.End {
+				return fmt.Errorf("malformed edit: pos (%v) > end (%v)", edit.Pos, edit.End)
+			}
+			file, endfile := act.pkg.Fset.File(edit.Pos), act.pkg.Fset.File(edit.End)
+			if file == nil || endfile == nil || file != endfile {
+				return fmt.Errorf("edit spanning files %v and %v", file.Name(), endfile.Name())
+			}
+			e := fileEdit{file.Name(), offsetedit{file.Offset(edit.Pos), file.Offset(edit.End), edit.NewText}}
 
-	editsForFile := make(map[*token.File]*node)
+			// TODO(matloob): Validate that edits do not affect other packages.
+			var tree *node
+			for _, prev := range edits {
+				if prev.name == e.name {
+					insert(&tree, prev.offsetedit)
+				}
+			}
+			if o := overlapping(tree, e.offsetedit); o != nil {
+				return fmt.Errorf("overlapping text edits affecting pos range (%v, %v) and (%v, %v)",
+					e.start, e.end, o.start, o.end)
+			}
+			if o := overlapping(editsForFile[e.name], e.offsetedit); o != nil {
+				if same(*o, e.offsetedit) {
+					continue // duplicate
+				}
+				return fmt.Errorf("text edit affecting pos range (%v, %v) overlaps another fix at (%v, %v)",
+					e.start, e.end, o.start, o.end)
+			}
+			edits = append(edits, e)
+		}
+		for _, e := range edits {
+			root := editsForFile[e.name]
+			insert(&root, e.offsetedit)
+			editsForFile[e.name] = root // In case the root changed
+		}
+		return nil
+	}
 
-	apply = func(act *action) error {
+	apply = func(act *action) {
+		// Only the diagnostics of the root packages are reported, while
+		// the analyzers may also run on the dependencies for the facts.
+		if !act.isroot {
+			return
+		}
 		for _, diag := range act.diagnostics {
 			for _, sf := range diag.SuggestedFixes {
-				for _, edit := range sf.TextEdits {
-					// Validate the edit.
-					if edit.Pos > edit.End {
-						return fmt.Errorf(
-							"diagnostic for analysis %v contains Suggested Fix with malformed edit: pos (%v) > end (%v)",
-							act.a.Name, edit.Pos, edit.End)
-					}
-					file, endfile := act.pkg.Fset.File(edit.Pos), act.pkg.Fset.File(edit.End)
-					if file == nil || endfile == nil || file != endfile {
-						return (fmt.Errorf(
-							"diagnostic for analysis %v contains Suggested Fix with malformed spanning files %v and %v",
-							act.a.Name, file.Name(), endfile.Name()))
-					}
-					start, end := file.Offset(edit.Pos), file.Offset(edit.End)
-
-					// TODO(matloob): Validate that edits do not affect other packages.
-					root := editsForFile[file]
-					if err := insert(&root, offsetedit{start, end, edit.NewText}); err != nil {
-						return err
-					}
-					editsForFile[file] = root // In case the root changed
+				if err := accept(act, sf); err != nil {
+					posn := act.pkg.Fset.Position(diag.Pos)
+					fmt.Fprintf(os.Stderr, "%s: %s: skipping suggested fix %q: %v\n", posn, act.a.Name, sf.Message, err)
 				}
 			}
 		}
-		return nil
 	}
 
 	visitAll(roots)
 
+	names := make([]string, 0, len(editsForFile))
+	for name := range editsForFile {
+		names = append(names, name)
+	}
+	sort.Strings(names) // for determinism
+
 	fset := token.NewFileSet() // Shared by parse calls below
 	// Now we've got a set of valid edits for each file. Get the new file contents.
-	for f, tree := range editsForFile {
-		contents, err := ioutil.ReadFile(f.Name())
+	for _, name := range names {
+		contents, err := ioutil.ReadFile(name)
 		if err != nil {
-			log.Fatal(err)
+			log.Print(err)
+			exitcode = 1
+			continue
 		}
 
 		cur := 0 // current position in the file
@@ -366,22 +461,22 @@ func applyFixes(roots []*action) {
 			edit := node.edit
 			if edit.start > cur {
 				out.Write(contents[cur:edit.start])
-				out.Write(edit.newText)
 			}
+			out.Write(edit.newText)
 			cur = edit.end
 
 			if node.right != nil {
 				recurse(node.right)
 			}
 		}
-		recurse(tree)
+		recurse(editsForFile[name])
 		// Write out the rest of the file.
 		if cur < len(contents) {
 			out.Write(contents[cur:])
 		}
 
 		// Try to format the file.
-		ff, err := parser.ParseFile(fset, f.Name(), out.Bytes(), parser.ParseComments)
+		ff, err := parser.ParseFile(fset, name, out.Bytes(), parser.ParseComments)
 		if err == nil {
 			var buf bytes.Buffer
 			if err = format.Node(&buf, fset, ff); err == nil {
@@ -389,19 +484,249 @@ func applyFixes(roots []*action) {
 			}
 		}
 
-		ioutil.WriteFile(f.Name(), out.Bytes(), 0644)
+		if Diff {
+			if d := diff.Unified(name, name, contents, out.Bytes()); d != "" {
+				fmt.Print(d)
+				if exitcode == 0 {
+					exitcode = 4 // fixes are pending
+				}
+			}
+			continue
+		}
+		if err := ioutil.WriteFile(name, out.Bytes(), 0644); err != nil {
+			log.Print(err)
+			exitcode = 1
+		}
 	}
+	return exitcode
+}
+
+// applyBaseline records the findings of the diagnostics in the
+// WriteBaseline file, and removes the ones recorded in the Baseline
+// file, which is the WriteBaseline file if it is not set, from the
+// diagnostics to print. It prints the findings of the Baseline file
+// which are fixed in the files of the root packages.
+func applyBaseline(roots []*action) error {
+	var actions []*action
+	visited := make(map[*action]bool)
+	var visitAll func(actions []*action)
+	visitAll = func(acts []*action) {
+		for _, act := range acts {
+			if !visited[act] {
+				visited[act] = true
+				visitAll(act.deps)
+				actions = append(actions, act)
+			}
+		}
+	}
+	visitAll(roots)
+
+	// The diagnostics are fingerprinted in the order of the printing,
+	// and de-duplicated by position like the plain text output.
+	type key struct {
+		pos token.Position
+		end token.Position
+		*analysis.Analyzer
+		message string
+	}
+	fp := analysisflags.NewFingerprinter()
+	fingerprints := make(map[key]string)
+	current := &analysisflags.Baseline{Version: 1, Findings: []analysisflags.Finding{}}
+	files := make(map[string]bool) // the files of the root packages
+	for _, act := range actions {
+		if act.isroot {
+			for _, f := range act.pkg.CompiledGoFiles {
+				files[analysisflags.RelPath(f)] = true
+			}
+		}
+		for _, diag := range act.diagnostics {
+			k := key{act.pkg.Fset.Position(diag.Pos), act.pkg.Fset.Position(diag.End), act.a, diag.Message}
+			if _, ok := fingerprints[k]; !ok {
+				f := fp.Finding(act.pkg.Fset, act.a.Name, diag)
+				fingerprints[k] = f.Fingerprint
+				current.Findings = append(current.Findings, f)
+			}
+		}
+	}
+
+	if WriteBaseline != "" {
+		if err := current.Write(WriteBaseline); err != nil {
+			return err
+		}
+	}
+	baseline := current
+	if Baseline != "" {
+		var err error
+		if baseline, err = analysisflags.ReadBaseline(Baseline); err != nil {
+			return err
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, f := range baseline.Findings {
+		known[f.Fingerprint] = true
+	}
+	for _, act := range actions {
+		var diags []analysis.Diagnostic
+		for _, diag := range act.diagnostics {
+			k := key{act.pkg.Fset.Position(diag.Pos), act.pkg.Fset.Position(diag.End), act.a, diag.Message}
+			if !known[fingerprints[k]] {
+				diags = append(diags, diag)
+			}
+		}
+		act.diagnostics = diags
+	}
+
+	found := make(map[string]bool)
+	for _, f := range current.Findings {
+		found[f.Fingerprint] = true
+	}
+	var fixed []analysisflags.Finding
+	for _, f := range baseline.Findings {
+		if files[f.File] && !found[f.Fingerprint] {
+			fixed = append(fixed, f)
+		}
+	}
+	if len(fixed) > 0 {
+		fmt.Fprintf(os.Stderr, "fixed findings of the baseline (%d):\n", len(fixed))
+		for _, f := range fixed {
+			fmt.Fprintf(os.Stderr, "\t%s\n", f)
+		}
+	}
+	return nil
+}
+
+// keepChanged removes the diagnostics whose ranges are not on the
+// lines changed since the NewFromRev revision from the diagnostics
+// to print. The packages are still analyzed as a whole, so that the
+// facts of the unchanged code are used.
+func keepChanged(roots []*action) error {
+	changed, err := changedLines(NewFromRev)
+	if err != nil {
+		return err
+	}
+	// The names of git are free of symbolic links, unlike the
+	// ones of the packages loaded in a linked directory.
+	realpaths := make(map[string]string)
+	realpath := func(name string) string {
+		p, ok := realpaths[name]
+		if !ok {
+			var err error
+			if p, err = filepath.EvalSymlinks(name); err != nil {
+				p = name
+			}
+			realpaths[name] = p
+		}
+		return p
+	}
+	visited := make(map[*action]bool)
+	var visitAll func(actions []*action)
+	visitAll = func(actions []*action) {
+		for _, act := range actions {
+			if visited[act] {
+				continue
+			}
+			visited[act] = true
+			visitAll(act.deps)
+			var diags []analysis.Diagnostic
+			for _, diag := range act.diagnostics {
+				posn, end := act.pkg.Fset.Position(diag.Pos), act.pkg.Fset.Position(diag.End)
+				if !end.IsValid() || end.Filename != posn.Filename {
+					end = posn
+				}
+				lines, ok := changed[realpath(posn.Filename)]
+				for l := posn.Line; ok && l <= end.Line; l++ {
+					if lines == nil || lines[l] {
+						diags = append(diags, diag)
+						break
+					}
+				}
+			}
+			act.diagnostics = diags
+		}
+	}
+	visitAll(roots)
+	return nil
+}
+
+// changedLines returns the lines changed in the working tree of
+// the current directory since the merge base of the git revision
+// and HEAD, like the changes of a pull request, by the absolute
+// names of the files, where the untracked files have nil for all
+// lines. The changes of the revision since the merge base are not
+// in the working tree and are excluded.
+func changedLines(rev string) (map[string]map[int]bool, error) {
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", args...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return out, nil
+	}
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.EvalSymlinks(strings.TrimSpace(string(top)))
+	if err != nil {
+		return nil, err
+	}
+	base, err := git("merge-base", rev, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	unified, err := git("diff", "--no-color", "--no-ext-diff", "--no-prefix", "--unified=0", strings.TrimSpace(string(base)), "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git("ls-files", "--others", "--exclude-standard", "--full-name", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]map[int]bool)
+	for name, lines := range diff.ChangedLines(unified) {
+		changed[filepath.Join(root, filepath.FromSlash(name))] = lines
+	}
+	for _, name := range strings.Split(string(untracked), "\x00") {
+		if name != "" {
+			changed[filepath.Join(root, filepath.FromSlash(name))] = nil
+		}
+	}
+	return changed, nil
 }
 
 // printDiagnostics prints the diagnostics for the root packages in either
-// plain text or JSON format. JSON format also includes errors for any
-// dependencies.
+// plain text, JSON, SARIF, GitHub Actions workflow commands or GitLab
+// Code Quality (Code Climate) format. JSON and SARIF formats also
+// include errors for any dependencies.
+//
+// The findings recorded in the Baseline file and the diagnostics not on
+// the lines changed since the NewFromRev revision are not printed.
 //
-// It returns the exitcode: in plain mode, 0 for success, 1 for analysis
-// errors, and 3 for diagnostics. We avoid 2 since the flag package uses
-// it. JSON mode always succeeds at printing errors and diagnostics in a
-// structured form to stdout.
+// It returns the exitcode: in plain and GitHub modes, 0 for success, 1
+// for analysis errors, and 3 for diagnostics. We avoid 2 since the flag
+// package uses it. JSON and SARIF modes always succeed at printing errors
+// and diagnostics in a structured form to stdout. Code Quality mode
+// prints the diagnostics to stdout and the errors to stderr, and returns
+// 1 for analysis errors.
 func printDiagnostics(roots []*action) (exitcode int) {
+	if Baseline != "" || WriteBaseline != "" {
+		if err := applyBaseline(roots); err != nil {
+			log.Print(err)
+			return 1
+		}
+	}
+	if NewFromRev != "" {
+		if err := keepChanged(roots); err != nil {
+			log.Print(err)
+			return 1
+		}
+	}
+
 	// Print the output.
 	//
 	// Print diagnostics only for root packages,
@@ -419,8 +744,18 @@ func printDiagnostics(roots []*action) (exitcode int) {
 		}
 	}
 
-	if analysisflags.JSON {
+	switch analysisflags.Format {
+	case "json":
 		// JSON output
+		if analysisflags.JSONVersion == 2 {
+			tree := analysisflags.NewJSONTreeV2()
+			print = func(act *action) {
+				tree.Add(act.pkg.Fset, act.pkg.ID, act.a.Name, act.diagnostics, act.err)
+			}
+			visitAll(roots)
+			tree.Print()
+			break
+		}
 		tree := make(analysisflags.JSONTree)
 		print = func(act *action) {
 			var diags []analysis.Diagnostic
@@ -429,8 +764,32 @@ func printDiagnostics(roots []*action) (exitcode int) {
 		}
 		visitAll(roots)
 		tree.Print()
-	} else {
-		// plain text output
+	case "sarif":
+		// SARIF output
+		sarif := analysisflags.NewSARIFLog()
+		for _, act := range roots {
+			sarif.AddRun(act.a.Name)
+		}
+		print = func(act *action) {
+			sarif.Add(act.pkg.Fset, act.pkg.ID, act.a.Name, act.diagnostics, act.err)
+		}
+		visitAll(roots)
+		sarif.Print()
+	case "codeclimate":
+		// GitLab Code Quality output
+		report := analysisflags.NewCodeClimateReport()
+		print = func(act *action) {
+			if act.err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", act.a.Name, act.err)
+				exitcode = 1 // analysis failed, at least partially
+				return
+			}
+			report.Add(act.pkg.Fset, act.a.Name, act.diagnostics)
+		}
+		visitAll(roots)
+		report.Print()
+	default:
+		// plain text or GitHub Actions workflow commands output
 
 		// De-duplicate diagnostics by position (not token.Pos) to
 		// avoid double-reporting in source files that belong to
@@ -445,13 +804,17 @@ func printDiagnostics(roots []*action) (exitcode int) {
 
 		print = func(act *action) {
 			if act.err != nil {
-				fmt.Fprintf(os.Stderr, "%s: %v\n", act.a.Name, act.err)
+				if analysisflags.Format == "github" {
+					analysisflags.PrintGitHubError(act.pkg.ID, act.a.Name, act.err)
+				} else {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", act.a.Name, act.err)
+				}
 				exitcode = 1 // analysis failed, at least partially
 				return
 			}
 			for _, diag := range act.diagnostics {
-				// We don't display a.Name/f.Category
-				// as most users don't care.
+				// We don't display a.Name/f.Category in plain
+				// text as most users don't care.
 
 				posn := act.pkg.Fset.Position(diag.Pos)
 				end := act.pkg.Fset.Position(diag.End)
@@ -461,7 +824,11 @@ func printDiagnostics(roots []*action) (exitcode int) {
 				}
 				seen[k] = true
 
-				analysisflags.PrintPlain(act.pkg.Fset, diag)
+				if analysisflags.Format == "github" {
+					analysisflags.PrintGitHub(act.pkg.Fset, act.a.Name, diag)
+				} else {
+					analysisflags.PrintPlain(act.pkg.Fset, diag)
+				}
 			}
 		}
 		visitAll(roots)
@@ -1,12 +1,19 @@
 package checker_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/ast"
 	"io/ioutil"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/Matts966/knil/analyzer/knil"
+	"github.com/Matts966/knil/fullchecker/internal/analysisflags"
 	"github.com/Matts966/knil/fullchecker/internal/checker"
 	"github.com/Matts966/knil/fullchecker/internal/testenv"
 	"golang.org/x/tools/go/analysis"
@@ -20,48 +27,662 @@ var from, to string
 func TestApplyFixes(t *testing.T) {
 	testenv.NeedsGoPackages(t)
 
-	from = "bar"
-	to = "baz"
+	_, path := writeRename(t, renameSrc+`
+// the end
+`)
+	want := renamedSrc + `
+// the end
+`
+
+	preserve(t, &checker.Fix)
+	checker.Fix = true
+	checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer})
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := string(contents)
+	if got != want {
+		t.Errorf("contents of rewritten file\ngot: %s\nwant: %s", got, want)
+	}
+}
+
+func TestApplyGuardFixes(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	fixGuards := knil.Analyzer.Flags.Lookup("fix-guards").Value.String()
+	t.Cleanup(func() { knil.Analyzer.Flags.Set("fix-guards", fixGuards) })
+	if err := knil.Analyzer.Flags.Set("fix-guards", "true"); err != nil {
+		t.Fatal(err)
+	}
 
 	files := map[string]string{
-		"rename/test.go": `package rename
+		"guard/test.go": `package guard
 
-func Foo() {
-	bar := 12
-	_ = bar
+import "errors"
+
+var errEmpty = errors.New("empty")
+
+type T struct{ f int }
+
+func deref(p *T) (int, error) {
+	if p.f == 0 {
+		return 0, errEmpty
+	}
+	return p.f, nil
 }
 
-// the end
+func get() *T { return nil }
+
+func load() T {
+	p := get()
+	return *p
+}
+
+func show(p *T) {
+	println(p.f)
+}
+
+func size(p *T) int { return p.f }
+
+func open(n int) (*T, error) {
+	if n == 0 {
+		return nil, errEmpty
+	}
+	return &T{}, nil
+}
+
+// The guard follows the check of the error of open.
+func read(n int) (int, error) {
+	p, err := open(n)
+	if err != nil {
+		return 0, err
+	}
+	return p.f, nil
+}
+
+func use() {
+	deref(nil)
+	show(nil)
+	size(nil)
+}
+`,
+		// No error can describe the nil values without errors
+		// or fmt, and no guard returning nil is suggested.
+		"noerr/test.go": `package noerr
+
+type T struct{ f int }
+
+func deref(p *T) (int, error) {
+	return p.f, nil
+}
+
+func use() {
+	deref(nil)
+}
 `}
-	want := `package rename
+	want := `package guard
 
-func Foo() {
-	baz := 12
-	_ = baz
+import "errors"
+
+var errEmpty = errors.New("empty")
+
+type T struct{ f int }
+
+func deref(p *T) (int, error) {
+	if p == nil {
+		return 0, errors.New("p is nil")
+	}
+	if p.f == 0 {
+		return 0, errEmpty
+	}
+	return p.f, nil
 }
 
-// the end
+func get() *T { return nil }
+
+func load() T {
+	p := get()
+	if p == nil {
+		return T{}
+	}
+	return *p
+}
+
+func show(p *T) {
+	if p == nil {
+		return
+	}
+	println(p.f)
+}
+
+func size(p *T) int {
+	if p == nil {
+		return 0
+	}
+	return p.f
+}
+
+func open(n int) (*T, error) {
+	if n == 0 {
+		return nil, errEmpty
+	}
+	return &T{}, nil
+}
+
+// The guard follows the check of the error of open.
+func read(n int) (int, error) {
+	p, err := open(n)
+	if err != nil {
+		return 0, err
+	}
+	if p == nil {
+		return 0, errors.New("p is nil")
+	}
+	return p.f, nil
+}
+
+func use() {
+	deref(nil)
+	show(nil)
+	size(nil)
+}
 `
 
 	testdata, cleanup, err := analysistest.WriteFiles(files)
 	if err != nil {
 		t.Fatal(err)
 	}
-	path := filepath.Join(testdata, "src/rename/test.go")
+	defer cleanup()
+	path := filepath.Join(testdata, "src/guard/test.go")
+	preserve(t, &checker.Fix)
 	checker.Fix = true
-	checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer})
+	checker.Run([]string{"file=" + path}, []*analysis.Analyzer{knil.Analyzer})
 
 	contents, err := ioutil.ReadFile(path)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	got := string(contents)
-	if got != want {
+	if got := string(contents); got != want {
 		t.Errorf("contents of rewritten file\ngot: %s\nwant: %s", got, want)
 	}
 
-	defer cleanup()
+	path = filepath.Join(testdata, "src/noerr/test.go")
+	checker.Run([]string{"file=" + path}, []*analysis.Analyzer{knil.Analyzer})
+	contents, err = ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(contents); got != files["noerr/test.go"] {
+		t.Errorf("file without errors or fmt is rewritten:\n%s", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	_, path := writeRename(t, renameSrc)
+
+	preserve(t, &checker.Diff)
+	checker.Diff = true
+	var exitcode int
+	out := captureStdout(t, func() {
+		exitcode = checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer})
+	})
+
+	if exitcode != 4 {
+		t.Errorf("exit code = %d, want 4", exitcode)
+	}
+	want := "--- " + path + "\n+++ " + path + `
+@@ -1,6 +1,6 @@
+ package rename
+ 
+ func Foo() {
+-	bar := 12
+-	_ = bar
++	baz := 12
++	_ = baz
+ }
+`
+	if out != want {
+		t.Errorf("diff\ngot: %s\nwant: %s", out, want)
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != renameSrc {
+		t.Errorf("file is rewritten with -diff:\n%s", contents)
+	}
+}
+
+func TestOverlappingFixes(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	_, path := writeRename(t, renameSrc)
+
+	// The fixes of the second analyzer overlap
+	// the ones of the first one and are skipped.
+	other := &analysis.Analyzer{
+		Name:     "rename2",
+		Requires: []*analysis.Analyzer{inspect.Analyzer},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			return rename(pass, "qux")
+		},
+	}
+	preserve(t, &checker.Fix)
+	checker.Fix = true
+	checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer, other})
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(contents), renamedSrc; got != want {
+		t.Errorf("contents of rewritten file\ngot: %s\nwant: %s", got, want)
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	_, path := writeRename(t, renameSrc)
+
+	preserve(t, &analysisflags.Format, &analysisflags.Rules)
+	analysisflags.Format = "sarif"
+	analysisflags.Rules = map[string][]analysisflags.Rule{
+		"rename": {{ID: "rename", Severity: "info", Description: "renaming"}},
+	}
+	type result struct {
+		RuleID    string
+		Level     string
+		Locations []struct {
+			PhysicalLocation struct {
+				Region struct {
+					StartLine, StartColumn, EndLine, EndColumn int
+				}
+			}
+		}
+		PartialFingerprints map[string]string
+	}
+	sarif := func() []result {
+		out := captureStdout(t, func() {
+			checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer})
+		})
+		var log struct {
+			Version string
+			Runs    []struct {
+				Tool struct {
+					Driver struct {
+						Name  string
+						Rules []struct{ ID string }
+					}
+				}
+				Results []result
+			}
+		}
+		if err := json.Unmarshal([]byte(out), &log); err != nil {
+			t.Fatalf("invalid SARIF: %v\n%s", err, out)
+		}
+		// The analyzers required by the roots have no runs.
+		if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name != "rename" {
+			t.Fatalf("unexpected SARIF log:\n%s", out)
+		}
+		if rules := log.Runs[0].Tool.Driver.Rules; len(rules) != 1 || rules[0].ID != "rename" {
+			t.Errorf("rules = %v, want [rename]", rules)
+		}
+		return log.Runs[0].Results
+	}
+
+	results := sarif()
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	r := results[0]
+	region := r.Locations[0].PhysicalLocation.Region
+	if r.RuleID != "rename" || r.Level != "note" || region.StartLine != 4 || region.StartColumn != 2 || region.EndLine != 4 || region.EndColumn != 5 {
+		t.Errorf("unexpected result: %+v", r)
+	}
+
+	// The fingerprints are stable across line shifts
+	// and tell the diagnostics of the same text apart.
+	if err := ioutil.WriteFile(path, []byte("\n\n"+renameSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	shifted := sarif()
+	if len(shifted) != 2 {
+		t.Fatalf("got %d results after the shift, want 2", len(shifted))
+	}
+	for i := range results {
+		fp, shiftedFP := results[i].PartialFingerprints["knilFingerprint/v1"], shifted[i].PartialFingerprints["knilFingerprint/v1"]
+		if fp == "" || fp != shiftedFP {
+			t.Errorf("fingerprint of result %d = %q, %q after the shift", i, fp, shiftedFP)
+		}
+	}
+	if results[0].PartialFingerprints["knilFingerprint/v1"] == results[1].PartialFingerprints["knilFingerprint/v1"] {
+		t.Errorf("results have the same fingerprint")
+	}
+}
+
+func TestJSONV2(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	_, path := writeRename(t, renameSrc)
+
+	preserve(t, &analysisflags.Format, &analysisflags.JSONVersion, &analysisflags.Rules)
+	analysisflags.Format = "json"
+	analysisflags.JSONVersion = 2
+	analysisflags.Rules = map[string][]analysisflags.Rule{
+		"rename": {{ID: "rename", Nilness: "unknown"}},
+	}
+	out := captureStdout(t, func() {
+		checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer})
+	})
+
+	type position struct {
+		File                 string
+		Line, Column, Offset int
+	}
+	type diagnostic struct {
+		Posn           string
+		Start, End     position
+		Function       string
+		Nilness        string
+		Message        string
+		SuggestedFixes []struct {
+			Message string
+			Edits   []struct {
+				Start, End position
+				NewText    string `json:"new_text"`
+			}
+		} `json:"suggested_fixes"`
+	}
+	var tree struct {
+		Version  int
+		Packages map[string]map[string][]diagnostic
+	}
+	if err := json.Unmarshal([]byte(out), &tree); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if tree.Version != 2 {
+		t.Errorf("version = %d, want 2", tree.Version)
+	}
+	var diags []diagnostic
+	for _, pkg := range tree.Packages {
+		diags = append(diags, pkg["rename"]...)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("got %d diagnostics, want 2:\n%s", len(diags), out)
+	}
+	d := diags[0]
+	wantStart, wantEnd := position{path, 4, 2, 30}, position{path, 4, 5, 33}
+	if d.Posn != path+":4:2" || d.Start != wantStart || d.End != wantEnd || d.Function != "Foo" || d.Nilness != "unknown" {
+		t.Errorf("unexpected diagnostic: %+v", d)
+	}
+	if len(d.SuggestedFixes) != 1 || len(d.SuggestedFixes[0].Edits) != 1 {
+		t.Fatalf("unexpected suggested fixes: %+v", d.SuggestedFixes)
+	}
+	if e := d.SuggestedFixes[0].Edits[0]; e.Start != wantStart || e.End != wantEnd || e.NewText != "baz" {
+		t.Errorf("unexpected edit: %+v", e)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	testdata, path := writeRename(t, renameSrc)
+	baseline := filepath.Join(testdata, "baseline.json")
+
+	preserve(t, &checker.WriteBaseline, &checker.Baseline, &analysisflags.Format)
+	checker.WriteBaseline = baseline
+	exitcode := checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer})
+	checker.WriteBaseline = ""
+	if exitcode != 0 {
+		t.Errorf("exit code with -write-baseline = %d, want 0", exitcode)
+	}
+	b, err := analysisflags.ReadBaseline(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Findings) != 2 {
+		t.Fatalf("got %d findings in the baseline, want 2: %v", len(b.Findings), b.Findings)
+	}
+	if f := b.Findings[0]; f.Analyzer != "rename" || f.Function != "Foo" || f.Message != `renaming "bar" to "baz"` {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+
+	// The findings of the baseline are not reported
+	// after the lines are shifted, but the new ones are.
+	checker.Baseline = baseline
+	if err := ioutil.WriteFile(path, []byte("\n"+renameSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if exitcode := checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer}); exitcode != 0 {
+		t.Errorf("exit code with the baseline = %d, want 0", exitcode)
+	}
+	// The use of bar in a different statement is new,
+	// although the range of it has the same text.
+	src := strings.Replace(renameSrc, "_ = bar", "print(bar)", 1)
+	if err := ioutil.WriteFile(path, []byte("\n"+src+`
+func Bar() {
+	bar := 1
+	_ = bar
+}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	analysisflags.Format = "json"
+	out := captureStdout(t, func() {
+		checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer})
+	})
+	var tree map[string]map[string][]struct{ Posn string }
+	if err := json.Unmarshal([]byte(out), &tree); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	var got []string
+	for _, pkg := range tree {
+		for _, d := range pkg["rename"] {
+			got = append(got, d.Posn)
+		}
+	}
+	if want := []string{path + ":6:8", path + ":10:2", path + ":11:6"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got diagnostics at %v, want %v", got, want)
+	}
+}
+
+func TestNewFromRev(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	testdata, path := writeRename(t, renameSrc)
+
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=knil", "-c", "user.email=knil@example.com"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = testdata
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	// The revision is not an ancestor of the working tree, and
+	// its changes since the merge base are not reported.
+	git("checkout", "-q", "-b", "upstream")
+	if err := ioutil.WriteFile(path, []byte(strings.Replace(renameSrc, "bar := 12", "bar := 13", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("commit", "-q", "-a", "-m", "upstream")
+	git("checkout", "-q", "-")
+	// The new files are changed as a whole.
+	other := filepath.Join(testdata, "src/rename/other.go")
+	if err := ioutil.WriteFile(other, []byte("package rename\n\nvar bar int\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(renameSrc+`
+func Bar() {
+	bar := 1
+	_ = bar
+}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(testdata); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	preserve(t, &checker.NewFromRev, &analysisflags.Format)
+	checker.NewFromRev = "upstream"
+	analysisflags.Format = "json"
+	out := captureStdout(t, func() {
+		checker.Run([]string{"file=" + path, "file=" + other}, []*analysis.Analyzer{analyzer})
+	})
+	var tree map[string]map[string][]struct{ Posn string }
+	if err := json.Unmarshal([]byte(out), &tree); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	got := make(map[string]bool)
+	for _, pkg := range tree {
+		for _, d := range pkg["rename"] {
+			got[d.Posn] = true
+		}
+	}
+	want := map[string]bool{path + ":9:2": true, path + ":10:6": true, other + ":3:5": true}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got diagnostics at %v, want %v", got, want)
+	}
+}
+
+func TestGitHubAndCodeClimate(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	_, path := writeRename(t, renameSrc)
+
+	preserve(t, &analysisflags.Format, &analysisflags.Rules)
+	analysisflags.Rules = map[string][]analysisflags.Rule{
+		"rename": {{ID: "rename", Severity: "info"}},
+	}
+
+	analysisflags.Format = "github"
+	var exitcode int
+	out := captureStdout(t, func() {
+		exitcode = checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer})
+	})
+	if exitcode != 3 {
+		t.Errorf("exit code with -format=github = %d, want 3", exitcode)
+	}
+	want := "::notice file=" + path + `,line=4,col=2,endLine=4,endColumn=5,title=rename (rename)::renaming "bar" to "baz"` + "\n" +
+		"::notice file=" + path + `,line=5,col=6,endLine=5,endColumn=9,title=rename (rename)::renaming "bar" to "baz"` + "\n"
+	if out != want {
+		t.Errorf("workflow commands\ngot: %s\nwant: %s", out, want)
+	}
+
+	analysisflags.Format = "codeclimate"
+	out = captureStdout(t, func() {
+		exitcode = checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer})
+	})
+	if exitcode != 0 {
+		t.Errorf("exit code with -format=codeclimate = %d, want 0", exitcode)
+	}
+	var issues []struct {
+		CheckName   string `json:"check_name"`
+		Description string
+		Severity    string
+		Fingerprint string
+		Location    struct {
+			Path  string
+			Lines struct{ Begin, End int }
+		}
+	}
+	if err := json.Unmarshal([]byte(out), &issues); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("got %d issues, want 2:\n%s", len(issues), out)
+	}
+	i := issues[0]
+	if i.CheckName != "rename/rename" || i.Severity != "info" || i.Fingerprint == "" || i.Location.Path != path || i.Location.Lines.Begin != 4 || i.Location.Lines.End != 4 {
+		t.Errorf("unexpected issue: %+v", i)
+	}
+	if issues[0].Fingerprint == issues[1].Fingerprint {
+		t.Errorf("issues have the same fingerprint")
+	}
+}
+
+// renameSrc is the source of the rename package, where
+// the analyzer renames bar to baz as in renamedSrc.
+const renameSrc = `package rename
+
+func Foo() {
+	bar := 12
+	_ = bar
+}
+`
+
+const renamedSrc = `package rename
+
+func Foo() {
+	baz := 12
+	_ = baz
+}
+`
+
+// writeRename writes the rename package with the source src to
+// a temporary GOPATH removed after the test, and returns the
+// GOPATH and the path of the file. The analyzer renames bar to
+// baz in the test.
+func writeRename(t *testing.T, src string) (testdata, path string) {
+	testdata, cleanup, err := analysistest.WriteFiles(map[string]string{"rename/test.go": src})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cleanup)
+	preserve(t, &from, &to)
+	from = "bar"
+	to = "baz"
+	return testdata, filepath.Join(testdata, "src/rename/test.go")
+}
+
+// preserve restores the variables pointed by ptrs, such as
+// the flags, to the current values after the test.
+func preserve(t *testing.T, ptrs ...interface{}) {
+	for _, p := range ptrs {
+		v := reflect.ValueOf(p).Elem()
+		old := reflect.New(v.Type()).Elem()
+		old.Set(v)
+		t.Cleanup(func() { v.Set(old) })
+	}
+}
+
+// captureStdout returns what f writes to the standard output.
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan []byte)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		done <- b
+	}()
+	f()
+	os.Stdout = stdout
+	w.Close()
+	return string(<-done)
 }
 
 var analyzer = &analysis.Analyzer{
@@ -71,6 +692,10 @@ var analyzer = &analysis.Analyzer{
 }
 
 func run(pass *analysis.Pass) (interface{}, error) {
+	return rename(pass, to)
+}
+
+func rename(pass *analysis.Pass, to string) (interface{}, error) {
 	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
 	nodeFilter := []ast.Node{(*ast.Ident)(nil)}
 	inspect.Preorder(nodeFilter, func(n ast.Node) {
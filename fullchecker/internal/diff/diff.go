@@ -0,0 +1,235 @@
+// Package diff computes line-oriented differences between two texts
+// and formats them as unified diffs, and parses the changed lines of
+// unified diffs.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// context is the number of unchanged lines around each hunk.
+const context = 3
+
+// An op is an operation of a line-oriented edit script.
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Unified returns the unified diff transforming before into after,
+// with the file names from and to in the header, or the empty string
+// if the texts are equal.
+func Unified(from, to string, before, after []byte) string {
+	if bytes.Equal(before, after) {
+		return ""
+	}
+	ops := edits(splitLines(before), splitLines(after))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", from, to)
+	for i := 0; i < len(ops); {
+		// Skip to the next change.
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// The hunk spans the changes separated by at
+		// most 2*context unchanged lines.
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		stop := end + context
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+		writeHunk(&b, ops, start, stop)
+		i = stop
+	}
+	return b.String()
+}
+
+// writeHunk writes the hunk of ops[start:stop].
+func writeHunk(b *strings.Builder, ops []op, start, stop int) {
+	// The lines of the hunk in both texts, counted from 1.
+	fromLine, toLine := 1, 1
+	for _, o := range ops[:start] {
+		if o.kind != '+' {
+			fromLine++
+		}
+		if o.kind != '-' {
+			toLine++
+		}
+	}
+	var fromCount, toCount int
+	for _, o := range ops[start:stop] {
+		if o.kind != '+' {
+			fromCount++
+		}
+		if o.kind != '-' {
+			toCount++
+		}
+	}
+	// An empty range is numbered by the line before it.
+	if fromCount == 0 {
+		fromLine--
+	}
+	if toCount == 0 {
+		toLine--
+	}
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount))
+	for _, o := range ops[start:stop] {
+		b.WriteByte(o.kind)
+		b.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(line, count int) string {
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splitLines splits text into lines keeping the line breaks.
+func splitLines(text []byte) []string {
+	lines := strings.SplitAfter(string(text), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// edits returns the shortest edit script transforming a into b
+// with the algorithm of Myers.
+func edits(a, b []string) []op {
+	n, m := len(a), len(b)
+	max := n + m
+	// v[k+max] is the furthest x on the diagonal k, and
+	// trace holds v before each step for the backtracking.
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+max] < v[k+1+max]) {
+				x = v[k+1+max]
+			} else {
+				x = v[k-1+max] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[k+max] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, d)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack returns the edit script of the path found in d steps.
+func backtrack(a, b []string, trace [][]int, d int) []op {
+	max := len(a) + len(b)
+	var ops []op
+	x, y := len(a), len(b)
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k-1+max] < v[k+1+max]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+max]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			ops = append(ops, op{' ', a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, op{'-', a[x]})
+		}
+	}
+	for x > 0 {
+		x, y = x-1, y-1
+		ops = append(ops, op{' ', a[x]})
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// ChangedLines returns the lines of the new files changed by the
+// unified diff, counted from 1, by the names of the files without
+// the prefixes, such as the ones printed by git diff --no-prefix.
+// The files with no changed lines, such as the ones whose lines are
+// only deleted, are not in the result.
+func ChangedLines(unified []byte) map[string]map[int]bool {
+	changed := make(map[string]map[int]bool)
+	var name, prev string
+	for _, line := range strings.Split(string(unified), "\n") {
+		// The header of the new file follows the one of the old
+		// file, unlike an added line starting with "++ ".
+		header := strings.HasPrefix(prev, "--- ")
+		prev = line
+		switch {
+		case header && strings.HasPrefix(line, "+++ "):
+			name = strings.TrimPrefix(line, "+++ ")
+			if i := strings.IndexByte(name, '\t'); i >= 0 {
+				name = name[:i]
+			}
+			if s, err := strconv.Unquote(name); err == nil {
+				name = s
+			}
+			if name == "/dev/null" {
+				name = ""
+			}
+		case strings.HasPrefix(line, "@@ ") && name != "":
+			// @@ -l,s +l,s @@, where the count is 1 if omitted.
+			fields := strings.Fields(line)
+			if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+				continue
+			}
+			start, count := fields[2][1:], "1"
+			if i := strings.IndexByte(start, ','); i >= 0 {
+				start, count = start[:i], start[i+1:]
+			}
+			l, err1 := strconv.Atoi(start)
+			n, err2 := strconv.Atoi(count)
+			if err1 != nil || err2 != nil || n == 0 {
+				continue
+			}
+			if changed[name] == nil {
+				changed[name] = make(map[int]bool)
+			}
+			for i := l; i < l+n; i++ {
+				changed[name][i] = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,132 @@
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Matts966/knil/fullchecker/internal/diff"
+)
+
+func TestUnified(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{
+			name:   "equal",
+			before: "a\nb\n",
+			after:  "a\nb\n",
+			want:   "",
+		},
+		{
+			name:   "insert",
+			before: "a\nb\nc\n",
+			after:  "a\nb\nx\nc\n",
+			want: `--- a.go
++++ b.go
+@@ -1,3 +1,4 @@
+ a
+ b
++x
+ c
+`,
+		},
+		{
+			name:   "delete",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			after:  "1\n2\n3\n4\n6\n7\n8\n",
+			want: `--- a.go
++++ b.go
+@@ -2,7 +2,6 @@
+ 2
+ 3
+ 4
+-5
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name:   "hunks",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			after:  "0\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n13\n",
+			want: `--- a.go
++++ b.go
+@@ -1,4 +1,4 @@
+-1
++0
+ 2
+ 3
+ 4
+@@ -9,4 +9,4 @@
+ 9
+ 10
+ 11
+-12
++13
+`,
+		},
+		{
+			name:   "empty",
+			before: "",
+			after:  "a\n",
+			want: `--- a.go
++++ b.go
+@@ -0,0 +1 @@
++a
+`,
+		},
+		{
+			name:   "no newline",
+			before: "a\nb",
+			after:  "a\nc",
+			want: `--- a.go
++++ b.go
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+\ No newline at end of file
+`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := diff.Unified("a.go", "b.go", []byte(test.before), []byte(test.after))
+			if got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestChangedLines(t *testing.T) {
+	unified := `diff --git a.go a.go
+index 0e8a1c5..5b2f0d1 100644
+--- a.go
++++ a.go
+@@ -1,2 +1,3 @@ package a
+@@ -10 +11 @@ func f() {
+@@ -20,3 +21,0 @@ func g() {
+diff --git b.go b.go
+deleted file mode 100644
+--- b.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+diff --git "dir/c d.go" "dir/c d.go"
+--- "dir/c d.go"
++++ "dir/c d.go"
+@@ -0,0 +1,2 @@
++++ added
++x
+`
+	want := map[string]map[int]bool{
+		"a.go":       {1: true, 2: true, 3: true, 11: true},
+		"dir/c d.go": {1: true, 2: true},
+	}
+	if got := diff.ChangedLines([]byte(unified)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}